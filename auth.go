@@ -87,6 +87,7 @@ func (a *Auth) SignUp(ctx context.Context, req SignUpRequest) (*AuthResponse, er
 	endpoint := fmt.Sprintf("%s/auth/v1/signup", a.client.baseURL)
 
 	resp, err := a.client.httpClient.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
 		SetResult(&AuthResponse{}).
@@ -116,6 +117,7 @@ func (a *Auth) SignInWithPassword(ctx context.Context, req SignInRequest) (*Auth
 	endpoint := fmt.Sprintf("%s/auth/v1/token?grant_type=password", a.client.baseURL)
 
 	resp, err := a.client.httpClient.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
 		SetResult(&AuthResponse{}).
@@ -145,6 +147,7 @@ func (a *Auth) SignInWithOTP(ctx context.Context, req SignInRequest) error {
 	endpoint := fmt.Sprintf("%s/auth/v1/otp", a.client.baseURL)
 
 	resp, err := a.client.httpClient.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
 		Post(endpoint)
@@ -165,6 +168,7 @@ func (a *Auth) Verify(ctx context.Context, req VerifyRequest) (*AuthResponse, er
 	endpoint := fmt.Sprintf("%s/auth/v1/verify", a.client.baseURL)
 
 	resp, err := a.client.httpClient.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
 		SetResult(&AuthResponse{}).
@@ -194,6 +198,7 @@ func (a *Auth) ResetPassword(ctx context.Context, req ResetPasswordRequest) erro
 	endpoint := fmt.Sprintf("%s/auth/v1/recover", a.client.baseURL)
 
 	resp, err := a.client.httpClient.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
 		Post(endpoint)
@@ -214,6 +219,7 @@ func (a *Auth) UpdatePassword(ctx context.Context, req UpdatePasswordRequest, to
 	endpoint := fmt.Sprintf("%s/auth/v1/user", a.client.baseURL)
 
 	resp, err := a.client.httpClient.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
 		SetBody(req).
@@ -235,6 +241,7 @@ func (a *Auth) RefreshToken(ctx context.Context, req RefreshTokenRequest) (*Auth
 	endpoint := fmt.Sprintf("%s/auth/v1/token?grant_type=refresh_token", a.client.baseURL)
 
 	resp, err := a.client.httpClient.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
 		SetResult(&AuthResponse{}).
@@ -264,6 +271,7 @@ func (a *Auth) GetUser(ctx context.Context, token string) (*User, error) {
 	endpoint := fmt.Sprintf("%s/auth/v1/user", a.client.baseURL)
 
 	resp, err := a.client.httpClient.R().
+		SetContext(ctx).
 		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
 		SetResult(&User{}).
 		Get(endpoint)
@@ -289,6 +297,7 @@ func (a *Auth) SignOut(ctx context.Context, token string) error {
 	endpoint := fmt.Sprintf("%s/auth/v1/logout", a.client.baseURL)
 
 	resp, err := a.client.httpClient.R().
+		SetContext(ctx).
 		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
 		Post(endpoint)
 
@@ -0,0 +1,370 @@
+package supabaseorm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// DefaultBatchSize is the number of rows sent per request by InsertMany
+const DefaultBatchSize = 1000
+
+// InsertOption configures a bulk insert
+type InsertOption func(*insertConfig)
+
+type insertConfig struct {
+	batchSize       int
+	concurrency     int
+	continueOnError bool
+	returning       interface{}
+	csvColumns      []string
+	csvMapping      map[string]string
+}
+
+// BatchSize sets the number of rows sent per request
+func BatchSize(size int) InsertOption {
+	return func(c *insertConfig) {
+		c.batchSize = size
+	}
+}
+
+// Concurrency sets how many batches are sent in parallel
+func Concurrency(n int) InsertOption {
+	return func(c *insertConfig) {
+		c.concurrency = n
+	}
+}
+
+// ContinueOnError keeps sending the remaining batches after a batch fails
+// By default the insert stops at the first failed batch
+func ContinueOnError() InsertOption {
+	return func(c *insertConfig) {
+		c.continueOnError = true
+	}
+}
+
+// Returning requests the inserted rows and decodes them into dest, which
+// must be a pointer to a slice. Rows are returned in batch order
+func Returning(dest interface{}) InsertOption {
+	return func(c *insertConfig) {
+		c.returning = dest
+	}
+}
+
+// newInsertConfig applies opts over the defaults
+func newInsertConfig(opts []InsertOption) insertConfig {
+	config := insertConfig{
+		batchSize:   DefaultBatchSize,
+		concurrency: 1,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.batchSize <= 0 {
+		config.batchSize = DefaultBatchSize
+	}
+	if config.concurrency <= 0 {
+		config.concurrency = 1
+	}
+	return config
+}
+
+// BulkResult is the aggregated result of a bulk insert
+type BulkResult struct {
+	// Inserted is the number of rows in batches that succeeded
+	Inserted int
+	// Batches is the total number of batches
+	Batches int
+	// FailedBatches holds the indexes of batches that failed
+	FailedBatches []int
+}
+
+// BatchError is the error for a single failed batch
+type BatchError struct {
+	Batch int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch %d: %v", e.Batch, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// InsertMany inserts rows, a slice of structs or maps, in batches
+// Objects with different keys are supported: the union of all keys is sent
+// as the columns parameter and missing keys take the column default
+func (q *QueryBuilder) InsertMany(rows interface{}, opts ...InsertOption) (*BulkResult, error) {
+	config := newInsertConfig(opts)
+
+	if err := runHook(q.context(), rows, BeforeInserter.BeforeInsert); err != nil {
+		return nil, err
+	}
+
+	encoded, columns, err := encodeRows(rows, q.scopeValues())
+	if err != nil {
+		return nil, err
+	}
+
+	q.method = http.MethodPost
+
+	next := 0
+	produce := func() (*bulkBatch, error) {
+		if next >= len(encoded) {
+			return nil, nil
+		}
+		end := next + config.batchSize
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		body, err := json.Marshal(encoded[next:end])
+		if err != nil {
+			return nil, err
+		}
+		batch := &bulkBatch{body: body, start: next, rows: end - next}
+		next = end
+		return batch, nil
+	}
+
+	// inserted marks the rows of the batches that succeeded, for AfterInsert
+	var mu sync.Mutex
+	inserted := make([]bool, len(encoded))
+
+	result, err := q.runBatches(config, produce, func(ctx context.Context, batch *bulkBatch) ([]json.RawMessage, error) {
+		req := q.newRequest().SetQueryParam("columns", strings.Join(columns, ","))
+		returned, err := q.insertBatch(ctx, req, batch, config.returning != nil)
+		if err == nil {
+			mu.Lock()
+			for i := batch.start; i < batch.start+batch.rows; i++ {
+				inserted[i] = true
+			}
+			mu.Unlock()
+		}
+		return returned, err
+	})
+
+	i := 0
+	hookErr := eachModel(rows, func(model interface{}) error {
+		i++
+		if !inserted[i-1] {
+			return nil
+		}
+		return runHook(q.context(), model, AfterInserter.AfterInsert)
+	})
+
+	return result, errors.Join(err, hookErr)
+}
+
+// bulkBatch is an encoded batch of rows
+type bulkBatch struct {
+	body  []byte
+	start int
+	rows  int
+}
+
+// runBatches sends the batches returned by produce, until it returns nil,
+// using config.concurrency workers, and aggregates the result
+func (q *QueryBuilder) runBatches(config insertConfig, produce func() (*bulkBatch, error), send func(context.Context, *bulkBatch) ([]json.RawMessage, error)) (*BulkResult, error) {
+	result := &BulkResult{}
+	returned := make(map[int][]json.RawMessage)
+	batchErrs := make(map[int]error)
+
+	ctx, cancel := context.WithCancel(q.context())
+	defer cancel()
+
+	type job struct {
+		index int
+		batch *bulkBatch
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	stopped := false
+	jobs := make(chan job)
+
+	for w := 0; w < config.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				rows, err := send(ctx, j.batch)
+
+				mu.Lock()
+				if err != nil {
+					batchErrs[j.index] = &BatchError{Batch: j.index, Err: err}
+					if !config.continueOnError && !stopped {
+						stopped = true
+						cancel()
+					}
+				} else {
+					result.Inserted += j.batch.rows
+					returned[j.index] = rows
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var produceErr error
+send:
+	for i := 0; ; i++ {
+		batch, err := produce()
+		if err != nil {
+			produceErr = err
+			break
+		}
+		if batch == nil {
+			break
+		}
+
+		select {
+		case jobs <- job{index: i, batch: batch}:
+			result.Batches++
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var errs []error
+	for i := 0; i < result.Batches; i++ {
+		err, ok := batchErrs[i]
+		if !ok {
+			continue
+		}
+		// Batches cancelled because another one failed are not reported
+		if stopped && errors.Is(err, context.Canceled) && q.context().Err() == nil {
+			continue
+		}
+		result.FailedBatches = append(result.FailedBatches, i)
+		errs = append(errs, err)
+	}
+	if produceErr != nil {
+		errs = append(errs, produceErr)
+	}
+
+	if config.returning != nil {
+		var all []json.RawMessage
+		for i := 0; i < result.Batches; i++ {
+			all = append(all, returned[i]...)
+		}
+		data, err := json.Marshal(all)
+		if err != nil {
+			return result, err
+		}
+		if err := json.Unmarshal(data, config.returning); err != nil {
+			return result, fmt.Errorf("failed to decode returned rows: %w", err)
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// insertBatch sends a single batch and returns the inserted rows if requested
+func (q *QueryBuilder) insertBatch(ctx context.Context, req *resty.Request, batch *bulkBatch, returning bool) ([]json.RawMessage, error) {
+	ret := "return=minimal"
+	if returning {
+		ret = "return=representation"
+	}
+
+	req.SetContext(ctx).SetHeader("Prefer", q.preferHeader("missing=default", ret))
+
+	resp, err := q.send(req, batch.body)
+	if err != nil {
+		return nil, err
+	}
+
+	if !returning {
+		return nil, nil
+	}
+
+	var rows []json.RawMessage
+	if err := json.Unmarshal(resp.Body(), &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode returned rows: %w", err)
+	}
+
+	return rows, nil
+}
+
+// encodeRows marshals each element of rows, setting the scoped values, and
+// collects the union of their keys, in order of first appearance
+func encodeRows(rows interface{}, scoped map[string]interface{}) ([]json.RawMessage, []string, error) {
+	v := reflect.ValueOf(rows)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, nil, fmt.Errorf("InsertMany expects a slice, got %T", rows)
+	}
+
+	encoded := make([]json.RawMessage, 0, v.Len())
+	seen := make(map[string]bool)
+	var columns []string
+
+	for i := 0; i < v.Len(); i++ {
+		data, err := json.Marshal(encodeModelBody(v.Index(i).Interface(), true))
+		if err != nil {
+			return nil, nil, fmt.Errorf("row %d: %w", i, err)
+		}
+		if len(scoped) > 0 {
+			if data, err = setColumns(data, scoped); err != nil {
+				return nil, nil, fmt.Errorf("row %d: %w", i, err)
+			}
+		}
+
+		keys, err := objectKeys(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("row %d: %w", i, err)
+		}
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+
+		encoded = append(encoded, data)
+	}
+
+	return encoded, columns, nil
+}
+
+// objectKeys returns the keys of a JSON object in document order
+func objectKeys(data []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, tok.(string))
+
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,131 @@
+package supabaseorm
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// bulkServer records the batches it receives and fails batches whose first
+// row has the name "fail"
+type bulkServer struct {
+	mu      sync.Mutex
+	batches [][]map[string]interface{}
+	columns []string
+	prefers []string
+}
+
+func (s *bulkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var rows []map[string]interface{}
+	_ = json.Unmarshal(body, &rows)
+
+	s.mu.Lock()
+	s.batches = append(s.batches, rows)
+	s.columns = append(s.columns, r.URL.Query().Get("columns"))
+	s.prefers = append(s.prefers, r.Header.Get("Prefer"))
+	s.mu.Unlock()
+
+	if len(rows) > 0 && rows[0]["name"] == "fail" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"23502","message":"null value"}`))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(body)
+}
+
+func TestInsertManyBatchesAndColumns(t *testing.T) {
+	handler := &bulkServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key")
+
+	rows := []map[string]interface{}{
+		{"name": "a"},
+		{"name": "b", "email": "b@example.com"},
+		{"name": "c"},
+	}
+
+	var inserted []map[string]interface{}
+	result, err := client.Table("users").InsertMany(rows, BatchSize(2), Concurrency(2), Returning(&inserted))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Inserted != 3 || result.Batches != 2 {
+		t.Errorf("Expected 3 rows in 2 batches, got %d rows in %d batches", result.Inserted, result.Batches)
+	}
+
+	if len(inserted) != 3 || inserted[2]["name"] != "c" {
+		t.Errorf("Expected returned rows in order, got %v", inserted)
+	}
+
+	for i, columns := range handler.columns {
+		if columns != "name,email" {
+			t.Errorf("Expected columns to be name,email, got %s", columns)
+		}
+		if handler.prefers[i] != "missing=default,return=representation" {
+			t.Errorf("Unexpected Prefer header %s", handler.prefers[i])
+		}
+	}
+}
+
+func TestInsertManyStopsOnError(t *testing.T) {
+	handler := &bulkServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key")
+
+	rows := []map[string]string{{"name": "ok"}, {"name": "fail"}, {"name": "ok"}}
+
+	result, err := client.Table("users").InsertMany(rows, BatchSize(1))
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Batch != 1 {
+		t.Fatalf("Expected batch 1 to fail, got %v", err)
+	}
+
+	if len(handler.batches) != 2 {
+		t.Errorf("Expected insert to stop after the failed batch, got %d requests", len(handler.batches))
+	}
+	if result.Inserted != 1 || len(result.FailedBatches) != 1 {
+		t.Errorf("Expected 1 inserted and 1 failed batch, got %+v", result)
+	}
+}
+
+func TestInsertManyContinueOnError(t *testing.T) {
+	handler := &bulkServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key")
+
+	rows := []map[string]string{{"name": "fail"}, {"name": "ok"}, {"name": "fail"}}
+
+	result, err := client.Table("users").InsertMany(rows, BatchSize(1), ContinueOnError())
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if result.Inserted != 1 {
+		t.Errorf("Expected 1 row inserted, got %d", result.Inserted)
+	}
+	if len(result.FailedBatches) != 2 || result.FailedBatches[0] != 0 || result.FailedBatches[1] != 2 {
+		t.Errorf("Expected batches 0 and 2 to fail, got %v", result.FailedBatches)
+	}
+}
+
+func TestInsertManyRequiresSlice(t *testing.T) {
+	client := New("https://example.com", "test-api-key")
+
+	if _, err := client.Table("users").InsertMany(map[string]string{"name": "a"}); err == nil {
+		t.Error("Expected an error for non-slice rows")
+	}
+}
@@ -0,0 +1,343 @@
+package supabaseorm
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the responses of read queries, see WithCache
+type Cache interface {
+	// Get returns the response stored under key, unless it has expired
+	Get(ctx context.Context, key string) (*CachedResponse, bool)
+	// Set stores resp under key for ttl
+	Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration)
+	// Invalidate removes the responses tagged with any of tags
+	Invalidate(ctx context.Context, tags ...string)
+}
+
+// CachedResponse is a response stored in a Cache
+type CachedResponse struct {
+	Headers map[string]string
+	Body    []byte
+	// Tags name the tables the response was read from
+	Tags []string
+}
+
+// WithCache caches the responses of the client's table reads (Get, First,
+// ...) and RPC calls in cache for ttl. Inserts, updates and deletes through
+// the client invalidate the cached reads of their table. Function results
+// are only invalidated by InvalidateCache or their TTL, and Raw queries are
+// not cached
+//
+// Responses are cached per URL, schema, format and role/subject of the
+// JWT the request is sent with
+func WithCache(cache Cache, ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.cache = &cacheConfig{store: cache, ttl: ttl, generations: make(map[string]uint64)}
+	}
+}
+
+// NoCache reads the query's rows from the API even if the client caches reads
+func (q *QueryBuilder) NoCache() *QueryBuilder {
+	q.cache = cachePolicy{skip: true}
+	return q
+}
+
+// CacheTTL caches the query's rows for ttl instead of the client's TTL
+func (q *QueryBuilder) CacheTTL(ttl time.Duration) *QueryBuilder {
+	q.cache = cachePolicy{ttl: ttl}
+	return q
+}
+
+// InvalidateCache removes the cached reads of tables. The results of a
+// function called with RPC are named rpc/<function>
+func (c *Client) InvalidateCache(ctx context.Context, tables ...string) {
+	if c.cache == nil {
+		return
+	}
+	tags := make([]string, len(tables))
+	for i, table := range tables {
+		tags[i] = cacheTag(c.schema, table)
+	}
+	c.cache.invalidate(ctx, tags...)
+}
+
+// cacheConfig holds the cache of a client
+type cacheConfig struct {
+	store Cache
+	ttl   time.Duration
+
+	mu sync.Mutex
+	// generations counts the invalidations of each tag, so that a read
+	// overtaken by a write of its table is not stored
+	generations map[string]uint64
+}
+
+// generation returns the number of invalidations of tag
+func (c *cacheConfig) generation(tag string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generations[tag]
+}
+
+// set stores resp unless tag was invalidated since generation was read
+func (c *cacheConfig) set(ctx context.Context, key, tag string, generation uint64, resp *CachedResponse, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generations[tag] == generation {
+		c.store.Set(ctx, key, resp, ttl)
+	}
+}
+
+// invalidate removes the responses tagged with tags. Reads still in
+// flight are not stored afterwards
+func (c *cacheConfig) invalidate(ctx context.Context, tags ...string) {
+	c.mu.Lock()
+	for _, tag := range tags {
+		c.generations[tag]++
+	}
+	c.mu.Unlock()
+	c.store.Invalidate(ctx, tags...)
+}
+
+// cachePolicy holds the cache settings of a query
+type cachePolicy struct {
+	skip bool
+	ttl  time.Duration
+}
+
+type cachePolicyKey struct{}
+
+// cacheTag is the tag of the reads of a table
+func cacheTag(schema, table string) string {
+	if schema == "" {
+		return table
+	}
+	return schema + "." + table
+}
+
+// cacheMiddleware serves reads from the client's cache and invalidates it
+// on writes
+func (c *Client) cacheMiddleware(next RoundTripper) RoundTripper {
+	return RoundTripperFunc(func(req *Request) (*Response, error) {
+		var tag string
+		switch {
+		case req.Table != "":
+			tag = cacheTag(req.Schema, req.Table)
+		case req.Function != "" && req.Method == http.MethodGet:
+			tag = cacheTag(req.Schema, "rpc/"+req.Function)
+		default:
+			// Raw queries may read or write anything
+			return next.RoundTrip(req)
+		}
+
+		if req.Method != http.MethodGet {
+			resp, err := next.RoundTrip(req)
+			if req.Method != http.MethodHead {
+				c.cache.invalidate(req.Context, tag)
+			}
+			return resp, err
+		}
+
+		policy, _ := req.Context.Value(cachePolicyKey{}).(cachePolicy)
+		if policy.skip {
+			return next.RoundTrip(req)
+		}
+
+		key := c.cacheKey(req)
+		if cached, ok := c.cache.store.Get(req.Context, key); ok {
+			headers := make(map[string]string, len(cached.Headers))
+			for k, v := range cached.Headers {
+				headers[k] = v
+			}
+			return &Response{StatusCode: http.StatusOK, Headers: headers, Body: bytes.Clone(cached.Body)}, nil
+		}
+
+		generation := c.cache.generation(tag)
+		resp, err := next.RoundTrip(req)
+		// Streamed responses have no body to cache
+		if err != nil || resp.StatusCode != http.StatusOK || resp.Body == nil {
+			return resp, err
+		}
+
+		ttl := c.cache.ttl
+		if policy.ttl > 0 {
+			ttl = policy.ttl
+		}
+		c.cache.set(req.Context, key, tag, generation, &CachedResponse{
+			Headers: cachedHeaders(resp.Headers),
+			Body:    bytes.Clone(resp.Body),
+			Tags:    []string{tag},
+		}, ttl)
+		return resp, nil
+	})
+}
+
+// cachedHeaders keeps the response headers that describe the rows
+func cachedHeaders(headers map[string]string) map[string]string {
+	kept := make(map[string]string)
+	for _, key := range []string{"Content-Range", "Content-Type"} {
+		if value, ok := headers[key]; ok {
+			kept[key] = value
+		}
+	}
+	return kept
+}
+
+// cacheKey identifies the response to req: its URL and query, the headers
+// selecting the rows and format, and who is asking
+func (c *Client) cacheKey(req *Request) string {
+	var key strings.Builder
+	key.WriteString(req.Method + " " + req.URL + "?" + req.Query.Encode())
+	for _, header := range []string{"Accept", "Accept-Profile", "Range", "Prefer"} {
+		if value := req.Header.Get(header); value != "" {
+			key.WriteString("\n" + header + ": " + value)
+		}
+	}
+
+	key.WriteString("\nsubject: " + c.requestSubject(req))
+	return key.String()
+}
+
+// requestSubject returns the role and subject of the JWT req is sent with
+func (c *Client) requestSubject(req *Request) string {
+	authorization := req.Header.Get("Authorization")
+	if authorization == "" {
+		authorization = c.httpClient.Header.Get("Authorization")
+	}
+	return jwtSubject(strings.TrimPrefix(authorization, "Bearer "))
+}
+
+// jwtSubject returns the role and subject of a JWT, or a hash of the token
+// if it cannot be decoded. The token is not verified
+func jwtSubject(token string) string {
+	if parts := strings.Split(token, "."); len(parts) == 3 {
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		var claims struct {
+			Role    string `json:"role"`
+			Subject string `json:"sub"`
+		}
+		if err == nil && json.Unmarshal(payload, &claims) == nil && claims.Role != "" {
+			return claims.Role + ":" + claims.Subject
+		}
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryCache is an in-memory Cache that evicts the least recently used
+// responses beyond its maximum size
+type MemoryCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	tags    map[string]map[string]struct{}
+}
+
+type memoryEntry struct {
+	key     string
+	resp    *CachedResponse
+	expires time.Time
+}
+
+// NewMemoryCache returns a MemoryCache holding up to maxEntries responses,
+// or any number if maxEntries is 0
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+// Get returns the response stored under key, unless it has expired
+func (m *MemoryCache) Get(ctx context.Context, key string) (*CachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*memoryEntry)
+	if time.Now().After(entry.expires) {
+		m.remove(element)
+		return nil, false
+	}
+
+	m.lru.MoveToFront(element)
+	return entry.resp, true
+}
+
+// Set stores resp under key for ttl
+func (m *MemoryCache) Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+
+	m.entries[key] = m.lru.PushFront(&memoryEntry{key: key, resp: resp, expires: time.Now().Add(ttl)})
+	for _, tag := range resp.Tags {
+		if m.tags[tag] == nil {
+			m.tags[tag] = make(map[string]struct{})
+		}
+		m.tags[tag][key] = struct{}{}
+	}
+
+	for m.maxEntries > 0 && m.lru.Len() > m.maxEntries {
+		m.remove(m.lru.Back())
+	}
+}
+
+// Invalidate removes the responses tagged with any of tags
+func (m *MemoryCache) Invalidate(ctx context.Context, tags ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range m.tags[tag] {
+			if element, ok := m.entries[key]; ok {
+				m.remove(element)
+			}
+		}
+	}
+}
+
+// Len returns the number of stored responses, including expired ones not
+// yet removed
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+// remove removes an entry and its tags; m.mu must be held
+func (m *MemoryCache) remove(element *list.Element) {
+	entry := m.lru.Remove(element).(*memoryEntry)
+	delete(m.entries, entry.key)
+	for _, tag := range entry.resp.Tags {
+		delete(m.tags[tag], entry.key)
+		if len(m.tags[tag]) == 0 {
+			delete(m.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package supabaseorm
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// testJWT returns an unsigned JWT with the given role and subject
+func testJWT(role, subject string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"role":"` + role + `","sub":"` + subject + `"}`))
+	return header + "." + payload + ".signature"
+}
+
+// countryHandler answers reads with one country and writes with 204
+func countryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Range", "0-0/1")
+		w.Write([]byte(`[{"code":"FR"}]`))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestCache(t *testing.T) {
+	server := newCaptureServer(t, countryHandler)
+
+	cache := NewMemoryCache(0)
+	client := server.client(WithCache(cache, time.Minute))
+
+	for i := 0; i < 3; i++ {
+		var rows []map[string]interface{}
+		if err := client.Table("countries").Where("code", "eq", "FR").Get(&rows); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(rows) != 1 || rows[0]["code"] != "FR" {
+			t.Fatalf("Unexpected rows %v", rows)
+		}
+	}
+	if server.count() != 1 {
+		t.Errorf("Expected 1 request, got %d", server.count())
+	}
+
+	// A different query is a different entry
+	var first []map[string]interface{}
+	if err := client.Table("countries").First(&first); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if server.count() != 2 || cache.Len() != 2 {
+		t.Errorf("Expected 2 requests and entries, got %d and %d", server.count(), cache.Len())
+	}
+
+	// NoCache bypasses the cache
+	var rows []map[string]interface{}
+	client.Table("countries").Where("code", "eq", "FR").NoCache().Get(&rows)
+	if server.count() != 3 {
+		t.Errorf("Expected NoCache to send a request, got %d requests", server.count())
+	}
+
+	// Writes to the table invalidate its reads
+	if err := client.Table("countries").Where("code", "eq", "FR").Update(map[string]interface{}{"name": "France"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Expected the update to invalidate the cache, got %d entries", cache.Len())
+	}
+	client.Table("countries").Where("code", "eq", "FR").Get(&rows)
+	if server.count() != 5 {
+		t.Errorf("Expected a request after the update, got %d requests", server.count())
+	}
+}
+
+func TestCacheKeySubject(t *testing.T) {
+	server := newCaptureServer(t, countryHandler)
+
+	client := server.client(WithCache(NewMemoryCache(0), time.Minute))
+
+	get := func(token string) {
+		var rows []map[string]interface{}
+		q := client.Table("plans")
+		if token != "" {
+			q.Header("Authorization", "Bearer "+token)
+		}
+		if err := q.Get(&rows); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	get(testJWT("authenticated", "alice"))
+	get(testJWT("authenticated", "alice"))
+	if server.count() != 1 {
+		t.Errorf("Expected the same subject to share the cache, got %d requests", server.count())
+	}
+	get(testJWT("authenticated", "bob"))
+	get("")
+	if server.count() != 3 {
+		t.Errorf("Expected each subject to have its own entries, got %d requests", server.count())
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	server := newCaptureServer(t, countryHandler)
+
+	client := server.client(WithCache(NewMemoryCache(0), time.Minute))
+
+	var rows []map[string]interface{}
+	client.Table("flags").CacheTTL(10 * time.Millisecond).Get(&rows)
+	time.Sleep(20 * time.Millisecond)
+	client.Table("flags").CacheTTL(10 * time.Millisecond).Get(&rows)
+	if server.count() != 2 {
+		t.Errorf("Expected the entry to expire, got %d requests", server.count())
+	}
+
+	client.Table("flags").Where("enabled", "eq", true).Get(&rows)
+	client.InvalidateCache(context.Background(), "flags")
+	client.Table("flags").Where("enabled", "eq", true).Get(&rows)
+	if server.count() != 4 {
+		t.Errorf("Expected InvalidateCache to remove the entry, got %d requests", server.count())
+	}
+}
+
+func TestCacheRPC(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusOK, `[{"name":"Paris"}]`))
+
+	client := server.client(WithCache(NewMemoryCache(0), time.Minute))
+	call := func() {
+		var rows []map[string]interface{}
+		args := map[string]interface{}{"tags": []string{"a", "b c"}}
+		if err := client.RPC("nearby", args).Where("name", "like", "P*").Get(&rows); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(rows) != 1 || rows[0]["name"] != "Paris" {
+			t.Fatalf("Unexpected rows %v", rows)
+		}
+	}
+
+	call()
+	call()
+	if server.count() != 1 {
+		t.Errorf("Expected RPC GET reads to be cached, got %d requests", server.count())
+	}
+
+	client.InvalidateCache(context.Background(), "rpc/nearby")
+	call()
+	if server.count() != 2 {
+		t.Errorf("Expected InvalidateCache to remove the function results, got %d requests", server.count())
+	}
+
+	// Raw queries are sent every time
+	for i := 0; i < 2; i++ {
+		client.Table("").Raw("select 1").Get(&[]map[string]interface{}{})
+	}
+	if server.count() != 4 {
+		t.Errorf("Expected Raw queries not to be cached, got %d requests", server.count())
+	}
+}
+
+func TestCacheWriteDuringRead(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := newCaptureServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			close(started)
+			<-release
+		}
+		countryHandler(w, r)
+	})
+
+	cache := NewMemoryCache(0)
+	client := server.client(WithCache(cache, time.Minute))
+
+	done := make(chan error)
+	go func() {
+		var rows []map[string]interface{}
+		done <- client.Table("countries").Get(&rows)
+	}()
+	<-started
+	if err := client.Table("countries").Where("code", "eq", "FR").Update(map[string]interface{}{"name": "France"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cache.Len() != 0 {
+		t.Errorf("Expected the read overtaken by the update not to be cached, got %d entries", cache.Len())
+	}
+}
+
+func TestMemoryCacheLRU(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(2)
+
+	cache.Set(ctx, "a", &CachedResponse{Body: []byte("a"), Tags: []string{"t1"}}, time.Minute)
+	cache.Set(ctx, "b", &CachedResponse{Body: []byte("b"), Tags: []string{"t2"}}, time.Minute)
+	cache.Get(ctx, "a")
+	cache.Set(ctx, "c", &CachedResponse{Body: []byte("c"), Tags: []string{"t2"}}, time.Minute)
+
+	if _, ok := cache.Get(ctx, "b"); ok {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	if _, ok := cache.Get(ctx, "a"); !ok {
+		t.Error("Expected a to be kept")
+	}
+
+	cache.Invalidate(ctx, "t2")
+	if _, ok := cache.Get(ctx, "c"); ok {
+		t.Error("Expected c to be invalidated")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Expected 1 entry, got %d", cache.Len())
+	}
+}
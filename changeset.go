@@ -0,0 +1,231 @@
+package supabaseorm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// UpdateFields updates only the given fields of data
+// Fields may be named by column, json name or Go field name
+func (q *QueryBuilder) UpdateFields(data interface{}, fields ...string) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("UpdateFields requires at least one field")
+	}
+
+	if err := runHook(q.context(), data, BeforeUpdater.BeforeUpdate); err != nil {
+		return err
+	}
+
+	values, err := writeValues(data)
+	if err != nil {
+		return err
+	}
+
+	partial := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		column := fieldColumn(data, field)
+		value, ok := values[column]
+		if !ok {
+			return fmt.Errorf("unknown or read-only field %q", field)
+		}
+		partial[column] = value
+	}
+
+	q.method = http.MethodPatch
+	if err := q.execute(partial); err != nil {
+		return err
+	}
+
+	return runHook(q.context(), data, AfterUpdater.AfterUpdate)
+}
+
+// Changeset tracks changes to a model so that only modified columns are
+// sent on update
+//
+//	cs, _ := NewChangeset(&user)
+//	user.Email = "new@example.com"
+//	err := client.Table("users").Where("id", "eq", user.ID).UpdateChanges(cs)
+type Changeset struct {
+	model    interface{}
+	original map[string][]byte
+}
+
+// NewChangeset snapshots the current values of model, a pointer to a struct
+func NewChangeset(model interface{}) (*Changeset, error) {
+	if v := reflect.ValueOf(model); v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("changeset model must be a pointer to a struct, got %T", model)
+	}
+
+	cs := &Changeset{model: model}
+	if err := cs.Reset(); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// Reset takes a new snapshot, e.g. after the changes have been saved
+func (cs *Changeset) Reset() error {
+	snapshot, err := encodedValues(cs.model)
+	if err != nil {
+		return err
+	}
+	cs.original = snapshot
+	return nil
+}
+
+// Changes returns the columns whose values differ from the snapshot
+func (cs *Changeset) Changes() (map[string]interface{}, error) {
+	values, err := writeValues(cs.model)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := encodedValues(cs.model)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]interface{})
+	for column, encoded := range current {
+		if original, ok := cs.original[column]; !ok || !bytes.Equal(original, encoded) {
+			changes[column] = values[column]
+		}
+	}
+
+	return changes, nil
+}
+
+// ChangedColumns returns the sorted names of the changed columns
+func (cs *Changeset) ChangedColumns() ([]string, error) {
+	changes, err := cs.Changes()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, len(changes))
+	for column := range changes {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	return columns, nil
+}
+
+// UpdateChanges sends the changed columns of the changeset's model
+// Nothing is sent when there are no changes. On success the changeset is
+// reset, and the model is refreshed if a representation was requested
+func (q *QueryBuilder) UpdateChanges(cs *Changeset) error {
+	if err := runHook(q.context(), cs.model, BeforeUpdater.BeforeUpdate); err != nil {
+		return err
+	}
+
+	changes, err := cs.Changes()
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	q.method = http.MethodPatch
+	resp, err := q.send(q.newRequest(), changes)
+	if err != nil {
+		return err
+	}
+
+	if body := resp.Body(); len(body) > 0 {
+		if err := decodeRepresentation(body, cs.model); err != nil {
+			return err
+		}
+	}
+
+	if err := cs.Reset(); err != nil {
+		return err
+	}
+
+	return runHook(q.context(), cs.model, AfterUpdater.AfterUpdate)
+}
+
+// decodeRepresentation decodes a returned row, or the first row of an
+// array, into model
+func decodeRepresentation(body []byte, model interface{}) error {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var rows []json.RawMessage
+		if err := json.Unmarshal(trimmed, &rows); err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		trimmed = rows[0]
+	}
+	return json.Unmarshal(trimmed, model)
+}
+
+// writeValues returns the columns of data that an update may send. Structs
+// give every writable column, including zero omitempty fields, so that
+// clearing one is seen as a change
+func writeValues(data interface{}) (map[string]interface{}, error) {
+	if v := reflect.Indirect(reflect.ValueOf(data)); v.Kind() == reflect.Struct {
+		info, err := ModelOf(v.Type())
+		if err != nil {
+			return nil, err
+		}
+		return info.writableValues(v.Interface()), nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &raw); err != nil {
+		return nil, fmt.Errorf("update data must encode to a JSON object: %w", err)
+	}
+
+	values := make(map[string]interface{}, len(raw))
+	for column, value := range raw {
+		values[column] = value
+	}
+	return values, nil
+}
+
+// encodedValues returns the JSON encoding of each column of data, for comparison
+func encodedValues(data interface{}) (map[string][]byte, error) {
+	values, err := writeValues(data)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := make(map[string][]byte, len(values))
+	for column, value := range values {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", column, err)
+		}
+		encoded[column] = b
+	}
+	return encoded, nil
+}
+
+// fieldColumn maps a Go field or json name of a struct model to its column
+func fieldColumn(data interface{}, field string) string {
+	info, err := ModelOf(data)
+	if err != nil {
+		return field
+	}
+
+	for _, f := range info.Fields {
+		if f.Name == field || f.JSONName == field {
+			return f.Column
+		}
+	}
+	return field
+}
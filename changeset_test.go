@@ -0,0 +1,118 @@
+package supabaseorm
+
+import (
+	"net/http"
+	"testing"
+)
+
+type changesetUser struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Active bool   `json:"active"`
+}
+
+func TestUpdateFields(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusOK, ""))
+	client := server.client()
+
+	user := changesetUser{ID: 1, Name: "Ada", Email: "ada@example.com"}
+	if err := client.Table("users").Where("id", "eq", 1).UpdateFields(user, "name", "active"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if sent := server.requests()[0].jsonBody(); len(sent) != 2 || sent["name"] != "Ada" || sent["active"] != false {
+		t.Errorf("Expected only name and active to be sent, got %v", sent)
+	}
+
+	// Untagged models are named by Go field too
+	if err := client.Table("users").Where("id", "eq", 1).UpdateFields(&user, "Name", "Email"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sent := server.requests()[1].jsonBody(); len(sent) != 2 || sent["name"] != "Ada" || sent["email"] != "ada@example.com" {
+		t.Errorf("Expected only name and email to be sent, got %v", sent)
+	}
+
+	if err := client.Table("users").Where("id", "eq", 1).UpdateFields(user, "nope"); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
+
+func TestUpdateFieldsTaggedModel(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusOK, ""))
+
+	author := modelAuthor{ID: 1, Name: "Ada", Email: "ada@example.com"}
+	if err := server.client().Table("authors").Where("id", "eq", 1).UpdateFields(&author, "Email"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if sent := server.requests()[0].jsonBody(); len(sent) != 1 || sent["email_address"] != "ada@example.com" {
+		t.Errorf("Expected only email_address to be sent, got %v", sent)
+	}
+}
+
+func TestChangeset(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusOK, `[{"id":1,"name":"Ada","email":"new@example.com","active":true}]`))
+	client := server.client()
+
+	user := changesetUser{ID: 1, Name: "Ada", Email: "ada@example.com"}
+	cs, err := NewChangeset(&user)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// No changes: nothing is sent
+	if err := client.Table("users").Where("id", "eq", 1).UpdateChanges(cs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if server.count() != 0 {
+		t.Errorf("Expected no request without changes, got %d", server.count())
+	}
+
+	user.Email = "new@example.com"
+	columns, _ := cs.ChangedColumns()
+	if len(columns) != 1 || columns[0] != "email" {
+		t.Errorf("Expected email to be changed, got %v", columns)
+	}
+
+	q := client.Table("users").Where("id", "eq", 1)
+	q.Header("Prefer", "return=representation")
+	if err := q.UpdateChanges(cs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if sent := server.requests()[0].jsonBody(); len(sent) != 1 || sent["email"] != "new@example.com" {
+		t.Errorf("Expected only email to be sent, got %v", sent)
+	}
+	if !user.Active {
+		t.Error("Expected model to be refreshed from the representation")
+	}
+
+	if columns, _ := cs.ChangedColumns(); len(columns) != 0 {
+		t.Errorf("Expected changeset to be reset, got %v", columns)
+	}
+}
+
+func TestChangesetClearsOmitEmptyField(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusOK, ""))
+	client := server.client()
+
+	user := struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email,omitempty"`
+	}{ID: 1, Name: "Ada", Email: "ada@example.com"}
+	cs, err := NewChangeset(&user)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	user.Email = ""
+	if err := client.Table("users").Where("id", "eq", 1).UpdateChanges(cs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	requests := server.requests()
+	if len(requests) != 1 || string(requests[0].body) != `{"email":""}` {
+		t.Errorf("Expected the cleared email to be sent, got %+v", requests)
+	}
+}
@@ -0,0 +1,276 @@
+package supabaseorm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is matched by the error of requests rejected by an open
+// circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned, wrapped by the HTTP client, when a request
+// is rejected without being sent because its API's circuit is open
+type CircuitOpenError struct {
+	Route string
+	// RetryAfter is the time left until a trial request is allowed
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open for %s, retry in %s", e.Route, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Is reports whether target is ErrCircuitOpen
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through and counts failures
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests until the cool-down has passed
+	CircuitOpen
+	// CircuitHalfOpen lets trial requests through to decide whether to close
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerConfig configures the circuit breakers of a client
+// Each API (RouteREST, RouteAuth, RouteStorage, ...) has its own breaker
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit, 5 by default
+	FailureThreshold int
+	// CoolDown is how long the circuit stays open before a trial request,
+	// 30 seconds by default
+	CoolDown time.Duration
+	// HalfOpenRequests is the number of trial requests allowed at once while
+	// half-open, 1 by default
+	HalfOpenRequests int
+	// IsFailure reports whether a request failed, by default network errors
+	// and 5xx responses. Requests cancelled by their context are not counted
+	IsFailure func(resp *http.Response, err error) bool
+	// OnStateChange is called when the circuit of a route changes state
+	OnStateChange func(route string, from, to CircuitState)
+}
+
+// WithCircuitBreaker fails requests fast while their API is failing
+func WithCircuitBreaker(config CircuitBreakerConfig) ClientOption {
+	return func(c *Client) {
+		if config.FailureThreshold <= 0 {
+			config.FailureThreshold = 5
+		}
+		if config.CoolDown <= 0 {
+			config.CoolDown = 30 * time.Second
+		}
+		if config.HalfOpenRequests <= 0 {
+			config.HalfOpenRequests = 1
+		}
+		if config.IsFailure == nil {
+			config.IsFailure = isServerFailure
+		}
+		c.breakers = &circuitBreakers{config: config, routes: make(map[string]*circuitBreaker)}
+	}
+}
+
+// CircuitState returns the state of the circuit breaker of route, e.g.
+// RouteREST. It is CircuitClosed when circuit breaking is not enabled
+func (c *Client) CircuitState(route string) CircuitState {
+	if c.breakers == nil {
+		return CircuitClosed
+	}
+	return c.breakers.get(route).currentState()
+}
+
+// isServerFailure reports network errors and 5xx responses
+func isServerFailure(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= 500
+}
+
+// circuitRoutes are the APIs that have their own breaker
+var circuitRoutes = []string{RouteREST, RouteAuth, RouteStorage, RouteFunctions, RouteRealtime}
+
+// circuitBreakers holds the breaker of each route
+type circuitBreakers struct {
+	config CircuitBreakerConfig
+
+	mu     sync.Mutex
+	routes map[string]*circuitBreaker
+}
+
+func (b *circuitBreakers) get(route string) *circuitBreaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.routes[route]
+	if !ok {
+		breaker = &circuitBreaker{route: route, config: &b.config}
+		b.routes[route] = breaker
+	}
+	return breaker
+}
+
+// circuitBreaker is the breaker of a single route
+type circuitBreaker struct {
+	route  string
+	config *CircuitBreakerConfig
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trials   int
+	changes  []stateChange
+}
+
+type stateChange struct {
+	from, to CircuitState
+}
+
+// unlock unlocks the breaker, then notifies OnStateChange of the changes
+// made while it was locked, so that the callback may query the breaker
+func (b *circuitBreaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+
+	if b.config.OnStateChange == nil {
+		return
+	}
+	for _, change := range changes {
+		b.config.OnStateChange(b.route, change.from, change.to)
+	}
+}
+
+// currentState returns the state, moving from open to half-open once the
+// cool-down has passed
+func (b *circuitBreaker) currentState() CircuitState {
+	b.mu.Lock()
+	defer b.unlock()
+
+	b.coolDown(time.Now())
+	return b.state
+}
+
+// allow reports whether a request may be sent, or the error rejecting it
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.unlock()
+
+	now := time.Now()
+	b.coolDown(now)
+
+	switch b.state {
+	case CircuitOpen:
+		return &CircuitOpenError{Route: b.route, RetryAfter: b.openedAt.Add(b.config.CoolDown).Sub(now)}
+	case CircuitHalfOpen:
+		if b.trials >= b.config.HalfOpenRequests {
+			return &CircuitOpenError{Route: b.route}
+		}
+		b.trials++
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a request
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	if b.state == CircuitHalfOpen && b.trials > 0 {
+		b.trials--
+	}
+
+	if !failed {
+		b.failures = 0
+		b.setState(CircuitClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.openedAt = time.Now()
+		b.setState(CircuitOpen)
+	}
+}
+
+// coolDown moves an open circuit to half-open once the cool-down has passed
+func (b *circuitBreaker) coolDown(now time.Time) {
+	if b.state == CircuitOpen && now.Sub(b.openedAt) >= b.config.CoolDown {
+		b.trials = 0
+		b.setState(CircuitHalfOpen)
+	}
+}
+
+// release gives back a trial request that ended without an outcome
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.unlock()
+
+	if b.state == CircuitHalfOpen && b.trials > 0 {
+		b.trials--
+	}
+}
+
+// setState changes the state, queueing the change for OnStateChange
+func (b *circuitBreaker) setState(state CircuitState) {
+	if b.state == state {
+		return
+	}
+	b.changes = append(b.changes, stateChange{from: b.state, to: state})
+	b.state = state
+}
+
+// circuitTransport rejects requests to routes whose circuit is open
+type circuitTransport struct {
+	next     http.RoundTripper
+	breakers *circuitBreakers
+}
+
+func (t *circuitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	route := ""
+	for _, r := range circuitRoutes {
+		if strings.Contains(req.URL.Path, r) {
+			route = r
+			break
+		}
+	}
+	if route == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	breaker := t.breakers.get(route)
+	if err := breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+
+	// Requests abandoned by the caller say nothing about the API
+	if err != nil && req.Context().Err() != nil {
+		breaker.release()
+		return resp, err
+	}
+
+	breaker.record(t.breakers.config.IsFailure(resp, err))
+	return resp, err
+}
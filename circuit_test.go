@@ -0,0 +1,102 @@
+package supabaseorm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var failing int32 = 1
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var changes []string
+	client := New(server.URL, "test-api-key", WithCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 2,
+		CoolDown:         50 * time.Millisecond,
+		OnStateChange: func(route string, from, to CircuitState) {
+			changes = append(changes, route+":"+to.String())
+		},
+	}))
+
+	var row map[string]interface{}
+	for i := 0; i < 2; i++ {
+		client.Table("users").Get(&row)
+	}
+	if state := client.CircuitState(RouteREST); state != CircuitOpen {
+		t.Fatalf("Expected the REST circuit to be open, got %s", state)
+	}
+
+	// Open: requests fail fast without reaching the server
+	err := client.Table("users").Get(&row)
+	var openErr *CircuitOpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) || openErr.Route != RouteREST {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls)
+	}
+
+	// Other APIs have their own breaker
+	if _, err := client.Auth().GetUser(context.Background(), "token"); errors.Is(err, ErrCircuitOpen) {
+		t.Error("Expected the auth circuit to be closed")
+	}
+
+	// After the cool-down, a successful trial closes the circuit
+	atomic.StoreInt32(&failing, 0)
+	time.Sleep(60 * time.Millisecond)
+	if err := client.Table("users").Get(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if state := client.CircuitState(RouteREST); state != CircuitClosed {
+		t.Errorf("Expected the REST circuit to be closed, got %s", state)
+	}
+
+	expected := []string{"/rest/v1/:open", "/rest/v1/:half-open", "/rest/v1/:closed"}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected state changes %v, got %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Expected state changes %v, got %v", expected, changes)
+			break
+		}
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key", WithCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		CoolDown:         20 * time.Millisecond,
+	}))
+
+	var row map[string]interface{}
+	client.Table("users").Get(&row)
+	time.Sleep(30 * time.Millisecond)
+	if state := client.CircuitState(RouteREST); state != CircuitHalfOpen {
+		t.Fatalf("Expected half-open after the cool-down, got %s", state)
+	}
+
+	client.Table("users").Get(&row)
+	if state := client.CircuitState(RouteREST); state != CircuitOpen {
+		t.Errorf("Expected a failed trial to reopen the circuit, got %s", state)
+	}
+}
@@ -12,8 +12,33 @@ import (
 type Client struct {
 	baseURL    string
 	apiKey     string
+	schema     string
 	httpClient *resty.Client
 	auth       *Auth
+	realtime   *Realtime
+	functions  *Functions
+
+	// softDeletes maps tables to their soft delete column
+	softDeletes map[string]string
+
+	// scopes filter every table query, see WithScope
+	scopes []scope
+
+	retry    *RetryPolicy
+	limiter  *requestLimits
+	breakers *circuitBreakers
+	otel     *telemetryConfig
+	log      *logConfig
+
+	// middleware wraps table and RPC queries, see WithMiddleware
+	middleware []Middleware
+
+	// cache serves table reads, see WithCache
+	cache *cacheConfig
+	// coalescer shares identical concurrent reads, see WithReadCoalescing
+	coalescer *coalescer
+	// recorder records or replays requests, see WithRecorder
+	recorder *recorder
 }
 
 // ClientOption is a function that configures a Client
@@ -33,6 +58,24 @@ func WithHeaders(headers map[string]string) ClientOption {
 	}
 }
 
+// WithSchema sets the default schema for table and RPC requests
+func WithSchema(schema string) ClientOption {
+	return func(c *Client) {
+		c.schema = schema
+	}
+}
+
+// WithSoftDelete makes Delete on table set column to the current time
+// instead of deleting rows, and excludes rows where it is set from queries
+func WithSoftDelete(table, column string) ClientOption {
+	return func(c *Client) {
+		if c.softDeletes == nil {
+			c.softDeletes = make(map[string]string)
+		}
+		c.softDeletes[table] = column
+	}
+}
+
 // New creates a new Supabase client
 func New(baseURL, apiKey string, options ...ClientOption) *Client {
 	httpClient := resty.New()
@@ -53,29 +96,105 @@ func New(baseURL, apiKey string, options ...ClientOption) *Client {
 		option(client)
 	}
 
+	// Requests go through the user middleware, then the cache, then the
+	// coalescer before being sent, so that user middleware sees cache hits
+	if client.cache != nil {
+		client.middleware = append(client.middleware, client.cacheMiddleware)
+	}
+	// Reads missing from the cache are coalesced
+	if client.coalescer != nil {
+		client.middleware = append(client.middleware, client.coalesceMiddleware)
+	}
+
+	// Install the retry and other transport layers
+	client.httpClient.SetTransport(client.wrapTransport(client.httpClient.GetClient().Transport))
+
 	// Initialize auth
 	client.auth = NewAuth(client)
 
+	// Initialize realtime
+	client.realtime = NewRealtime(client)
+
+	// Initialize functions
+	client.functions = NewFunctions(client)
+
 	return client
 }
 
+// wrapTransport wraps base with the transport layers enabled by the options
+func (c *Client) wrapTransport(base http.RoundTripper) http.RoundTripper {
+	transport := base
+	// Recorded requests stand in for the network, below every other layer
+	if c.recorder != nil {
+		transport = &recordTransport{next: transport, recorder: c.recorder}
+	}
+	if c.limiter != nil {
+		transport = &limitTransport{next: transport, limits: c.limiter}
+	}
+	// Retries go through the limits again
+	if c.retry != nil {
+		transport = newRetryTransport(transport, *c.retry)
+	}
+	// The breaker sees the outcome after retries, and rejects before them
+	if c.breakers != nil {
+		transport = &circuitTransport{next: transport, breakers: c.breakers}
+	}
+	// Log records carry the span of the request in their context
+	if c.log != nil && c.log.logger != nil {
+		transport = &logTransport{next: transport, config: *c.log}
+	}
+	// Spans cover the whole request, including retries and rejections
+	if c.otel != nil {
+		transport = newTelemetryTransport(transport, c.otel)
+	}
+	return transport
+}
+
 // Table returns a new query builder for the specified table
 func (c *Client) Table(tableName string) *QueryBuilder {
 	return &QueryBuilder{
-		client:    c,
-		tableName: tableName,
-		method:    http.MethodGet,
+		client:     c,
+		tableName:  tableName,
+		schema:     c.schema,
+		method:     http.MethodGet,
+		softDelete: c.softDeletes[tableName],
+		scopes:     c.scopes,
 	}
 }
 
+// Schema returns a client that sends requests to the specified schema
+// The returned client shares the HTTP client and settings of the original
+func (c *Client) Schema(schema string) *Client {
+	clone := *c
+	clone.schema = schema
+	return &clone
+}
+
 // Auth returns the Auth instance for authentication operations
 func (c *Client) Auth() *Auth {
 	return c.auth
 }
 
+// Realtime returns the Realtime instance for broadcast and presence channels
+func (c *Client) Realtime() *Realtime {
+	return c.realtime
+}
+
+// Functions returns the Functions instance for invoking Edge Functions
+func (c *Client) Functions() *Functions {
+	return c.functions
+}
+
 // RawRequest allows making raw HTTP requests to the Supabase API
+// The client's schema is selected with both Accept-Profile and
+// Content-Profile, as the method is not known yet
 func (c *Client) RawRequest() *resty.Request {
-	return c.httpClient.R()
+	req := c.httpClient.R()
+	if c.schema != "" {
+		req.SetHeader("Accept-Profile", c.schema)
+		req.SetHeader("Content-Profile", c.schema)
+	}
+	return req
 }
 
 // GetBaseURL returns the base URL of the Supabase API
@@ -83,6 +202,12 @@ func (c *Client) GetBaseURL() string {
 	return c.baseURL
 }
 
+// GetSchema returns the schema used for table and RPC requests
+// An empty schema means the API's default schema
+func (c *Client) GetSchema() string {
+	return c.schema
+}
+
 // GetAPIKey returns the API key used for authentication
 func (c *Client) GetAPIKey() string {
 	return c.apiKey
@@ -71,3 +71,24 @@ func TestTable(t *testing.T) {
 		t.Error("Expected client to be the same instance")
 	}
 }
+
+func TestSchema(t *testing.T) {
+	client := New("https://example.supabase.co", "test-api-key", WithSchema("public"))
+
+	if client.Table("users").schema != "public" {
+		t.Errorf("Expected schema to be public, got %s", client.Table("users").schema)
+	}
+
+	billing := client.Schema("billing")
+	if billing.Table("invoices").schema != "billing" {
+		t.Errorf("Expected schema to be billing, got %s", billing.Table("invoices").schema)
+	}
+
+	if client.GetSchema() != "public" {
+		t.Errorf("Expected original client schema to stay public, got %s", client.GetSchema())
+	}
+
+	if billing.httpClient != client.httpClient {
+		t.Error("Expected schema client to share the HTTP client")
+	}
+}
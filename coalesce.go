@@ -0,0 +1,129 @@
+package supabaseorm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WithReadCoalescing sends identical concurrent reads of the client only
+// once: a read with the same URL, headers and JWT subject as one in flight
+// waits for it and gets a copy of its response
+//
+// Streamed reads are not shared, and waiters whose read was cancelled by
+// its own context send their request themselves
+func WithReadCoalescing() ClientOption {
+	return func(c *Client) {
+		c.coalescer = &coalescer{flights: make(map[string]*flight)}
+	}
+}
+
+// coalescer tracks the reads in flight
+type coalescer struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a read in flight; resp and err are set before done is closed
+type flight struct {
+	done chan struct{}
+	resp *Response
+	err  error
+}
+
+// shared reports whether the outcome of the flight can be given to waiters
+func (f *flight) shared() bool {
+	if f.err != nil {
+		return !errors.Is(f.err, context.Canceled) && !errors.Is(f.err, context.DeadlineExceeded)
+	}
+	return f.resp != nil && f.resp.Body != nil
+}
+
+// result returns a copy of the flight's outcome, so that each caller may
+// decode the body and update the error on its own
+func (f *flight) result() (*Response, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	resp := &Response{
+		StatusCode: f.resp.StatusCode,
+		Headers:    make(map[string]string, len(f.resp.Headers)),
+		Body:       bytes.Clone(f.resp.Body),
+		Error:      f.resp.Error,
+	}
+	for k, v := range f.resp.Headers {
+		resp.Headers[k] = v
+	}
+	if apiErr, ok := f.resp.Error.(*PostgrestError); ok {
+		copied := *apiErr
+		resp.Error = &copied
+	}
+	return resp, nil
+}
+
+// coalesceMiddleware shares the responses of identical concurrent reads
+func (c *Client) coalesceMiddleware(next RoundTripper) RoundTripper {
+	return RoundTripperFunc(func(req *Request) (*Response, error) {
+		if req.Method != http.MethodGet {
+			return next.RoundTrip(req)
+		}
+
+		key := c.coalesceKey(req)
+		c.coalescer.mu.Lock()
+		if f, ok := c.coalescer.flights[key]; ok {
+			c.coalescer.mu.Unlock()
+			select {
+			case <-f.done:
+			case <-req.Context.Done():
+				return nil, req.Context.Err()
+			}
+			if f.shared() {
+				return f.result()
+			}
+			return next.RoundTrip(req)
+		}
+
+		f := &flight{done: make(chan struct{})}
+		c.coalescer.flights[key] = f
+		c.coalescer.mu.Unlock()
+
+		f.resp, f.err = next.RoundTrip(req)
+
+		c.coalescer.mu.Lock()
+		delete(c.coalescer.flights, key)
+		c.coalescer.mu.Unlock()
+		close(f.done)
+
+		// The original response is kept for streamed reads, which are not shared
+		if f.resp != nil && f.resp.Body == nil {
+			return f.resp, f.err
+		}
+		return f.result()
+	})
+}
+
+// coalesceKey identifies identical reads: same URL, query, headers and
+// JWT subject
+func (c *Client) coalesceKey(req *Request) string {
+	var key strings.Builder
+	key.WriteString(req.Method + " " + req.URL + "?" + req.Query.Encode())
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		if name != "Authorization" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key.WriteString("\n" + name + ": " + strings.Join(req.Header[name], ", "))
+	}
+
+	key.WriteString("\nsubject: " + c.requestSubject(req))
+	return key.String()
+}
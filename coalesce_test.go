@@ -0,0 +1,118 @@
+package supabaseorm
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReadCoalescing(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		if r.URL.Query().Get("key") == "eq.missing" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"42703","message":"column does not exist"}`))
+			return
+		}
+		w.Write([]byte(`[{"key":"theme","value":"dark"}]`))
+	}))
+	defer server.Close()
+
+	// Waiters join the first read while the server holds it
+	var waiting sync.WaitGroup
+	joined := func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(req *Request) (*Response, error) {
+			waiting.Done()
+			return next.RoundTrip(req)
+		})
+	}
+	client := New(server.URL, "key", WithReadCoalescing(), WithMiddleware(joined))
+
+	const readers = 20
+	waiting.Add(readers)
+	results := make([][]map[string]interface{}, readers)
+	errs := make([]error, readers)
+	var done sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			errs[i] = client.Table("config").Where("key", "eq", "theme").Get(&results[i])
+		}(i)
+	}
+	waiting.Wait()
+	close(release)
+	done.Wait()
+
+	if n := atomic.LoadInt32(&requests); n >= readers {
+		t.Errorf("Expected concurrent reads to be coalesced, got %d requests", n)
+	}
+	for i := 0; i < readers; i++ {
+		if errs[i] != nil {
+			t.Fatalf("Unexpected error: %v", errs[i])
+		}
+		if len(results[i]) != 1 || results[i][0]["value"] != "dark" {
+			t.Fatalf("Unexpected result %v", results[i])
+		}
+	}
+
+	// Each caller decodes its own copy
+	results[0][0]["value"] = "light"
+	if results[1][0]["value"] != "dark" {
+		t.Error("Results share their rows")
+	}
+
+	// API errors are shared too, each caller with its own error
+	atomic.StoreInt32(&requests, 0)
+	waiting.Add(2)
+	var first, second error
+	done.Add(2)
+	go func() {
+		defer done.Done()
+		var rows []map[string]interface{}
+		first = client.Table("config").Where("key", "eq", "missing").Get(&rows)
+	}()
+	go func() {
+		defer done.Done()
+		var rows []map[string]interface{}
+		second = client.Table("config").Where("key", "eq", "missing").Get(&rows)
+	}()
+	done.Wait()
+
+	var firstErr, secondErr *PostgrestError
+	if !errors.As(first, &firstErr) || !errors.As(second, &secondErr) || firstErr.Code != "42703" || firstErr == secondErr {
+		t.Errorf("Expected distinct API errors, got %v and %v", first, second)
+	}
+}
+
+func TestReadCoalescingKey(t *testing.T) {
+	client := New("http://localhost", "key", WithReadCoalescing())
+
+	request := func(authorization, prefer string) *Request {
+		req := &Request{Method: http.MethodGet, URL: "http://localhost/rest/v1/config", Header: http.Header{}}
+		if authorization != "" {
+			req.Header.Set("Authorization", "Bearer "+authorization)
+		}
+		if prefer != "" {
+			req.Header.Set("Prefer", prefer)
+		}
+		return req
+	}
+
+	alice := testJWT("authenticated", "alice")
+	if client.coalesceKey(request(alice, "")) != client.coalesceKey(request(alice, "")) {
+		t.Error("Expected identical reads to share a key")
+	}
+	if client.coalesceKey(request(alice, "")) == client.coalesceKey(request(testJWT("authenticated", "bob"), "")) {
+		t.Error("Expected reads of different subjects to have different keys")
+	}
+	if client.coalesceKey(request(alice, "")) == client.coalesceKey(request(alice, "count=exact")) {
+		t.Error("Expected reads with different headers to have different keys")
+	}
+}
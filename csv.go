@@ -0,0 +1,165 @@
+package supabaseorm
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// CSVColumns sets the column names for a CSV import whose input has no
+// header row
+func CSVColumns(columns ...string) InsertOption {
+	return func(c *insertConfig) {
+		c.csvColumns = columns
+	}
+}
+
+// CSVColumnMap renames CSV header fields to table columns
+// Fields mapped to an empty string are not imported
+func CSVColumnMap(mapping map[string]string) InsertOption {
+	return func(c *insertConfig) {
+		c.csvMapping = mapping
+	}
+}
+
+// ExportCSV streams the query results to w as CSV
+// Select, filters, order and pagination are applied as for Get
+func (q *QueryBuilder) ExportCSV(ctx context.Context, w io.Writer) error {
+	q.method = http.MethodGet
+
+	req := q.newRequest().
+		SetContext(ctx).
+		SetHeader("Accept", "text/csv").
+		SetDoNotParseResponse(true)
+
+	resp, err := q.send(req, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.RawBody().Close()
+
+	_, err = io.Copy(w, resp.RawBody())
+	return err
+}
+
+// ImportCSV reads CSV rows from r and inserts them in batches
+// The first row is the header unless CSVColumns is given. BatchSize,
+// Concurrency, ContinueOnError and Returning apply as for InsertMany
+func (q *QueryBuilder) ImportCSV(ctx context.Context, r io.Reader, opts ...InsertOption) (*BulkResult, error) {
+	config := newInsertConfig(opts)
+
+	reader := csv.NewReader(r)
+
+	header := config.csvColumns
+	if len(header) == 0 {
+		var err error
+		header, err = reader.Read()
+		if errors.Is(err, io.EOF) {
+			return &BulkResult{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+	}
+
+	// Work out which fields are imported and under which column name
+	var keep []int
+	var columns []string
+	for i, field := range header {
+		column := field
+		if mapped, ok := config.csvMapping[field]; ok {
+			column = mapped
+		}
+		if column == "" {
+			continue
+		}
+		keep = append(keep, i)
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns to import")
+	}
+
+	// Columns pinned by the scopes are filled in, or added when missing
+	pinned := make(map[int]string)
+	scoped := q.scopeValues()
+	for _, column := range sortedKeys(scoped) {
+		value := fmt.Sprintf("%v", scoped[column])
+		index := len(columns)
+		for i, c := range columns {
+			if c == column {
+				index = i
+			}
+		}
+		if index == len(columns) {
+			columns = append(columns, column)
+		}
+		pinned[index] = value
+	}
+
+	q.method = http.MethodPost
+	q.WithContext(ctx)
+
+	done := false
+	produce := func() (*bulkBatch, error) {
+		if done {
+			return nil, nil
+		}
+
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		if err := writer.Write(columns); err != nil {
+			return nil, err
+		}
+
+		rows := 0
+		for rows < config.batchSize {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				done = true
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CSV: %w", err)
+			}
+
+			fields := make([]string, len(columns))
+			for j, i := range keep {
+				if i < len(record) {
+					fields[j] = record[i]
+				}
+			}
+			for j, value := range pinned {
+				if fields[j] != "" && fields[j] != value {
+					return nil, fmt.Errorf("%w: column %s is %s, not %s", ErrUnscopedWrite, columns[j], fields[j], value)
+				}
+				fields[j] = value
+			}
+			if err := writer.Write(fields); err != nil {
+				return nil, err
+			}
+			rows++
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return nil, err
+		}
+
+		if rows == 0 {
+			return nil, nil
+		}
+
+		return &bulkBatch{body: buf.Bytes(), rows: rows}, nil
+	}
+
+	return q.runBatches(config, produce, func(ctx context.Context, batch *bulkBatch) ([]json.RawMessage, error) {
+		req := q.newRequest().SetHeader("Content-Type", "text/csv")
+		return q.insertBatch(ctx, req, batch, config.returning != nil)
+	})
+}
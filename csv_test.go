@@ -0,0 +1,115 @@
+package supabaseorm
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExportCSV(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/csv" {
+			t.Errorf("Expected Accept to be text/csv, got %s", r.Header.Get("Accept"))
+		}
+		if r.URL.Query().Get("select") != "id,name" || r.URL.Query().Get("order") != "id.asc" {
+			t.Errorf("Expected select and order to be applied, got %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("id,name\n1,Ada\n2,Grace\n"))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key")
+
+	var buf bytes.Buffer
+	err := client.Table("users").Select("id", "name").Order("id", "asc").ExportCSV(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if buf.String() != "id,name\n1,Ada\n2,Grace\n" {
+		t.Errorf("Unexpected CSV %q", buf.String())
+	}
+}
+
+func TestExportCSVError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"column does not exist"}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key")
+
+	err := client.Table("users").ExportCSV(context.Background(), io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "column does not exist") {
+		t.Errorf("Expected API error with body, got %v", err)
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "text/csv" {
+			t.Errorf("Expected Content-Type to be text/csv, got %s", r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key")
+
+	input := "Full Name,Email,Notes\nAda,ada@example.com,x\nGrace,grace@example.com,y\nAlan,alan@example.com,z\n"
+	result, err := client.Table("users").ImportCSV(context.Background(), strings.NewReader(input),
+		BatchSize(2),
+		CSVColumnMap(map[string]string{"Full Name": "name", "Email": "email", "Notes": ""}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Inserted != 3 || result.Batches != 2 {
+		t.Errorf("Expected 3 rows in 2 batches, got %+v", result)
+	}
+
+	expected := []string{
+		"name,email\nAda,ada@example.com\nGrace,grace@example.com\n",
+		"name,email\nAlan,alan@example.com\n",
+	}
+	for i, body := range bodies {
+		if body != expected[i] {
+			t.Errorf("Expected batch %d to be %q, got %q", i, expected[i], body)
+		}
+	}
+}
+
+func TestImportCSVWithoutHeader(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key")
+
+	_, err := client.Table("users").ImportCSV(context.Background(), strings.NewReader("1,Ada\n"), CSVColumns("id", "name"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if body != "id,name\n1,Ada\n" {
+		t.Errorf("Unexpected CSV body %q", body)
+	}
+}
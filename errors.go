@@ -0,0 +1,72 @@
+package supabaseorm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ErrNoRows is returned by Single when the query matched no rows
+var ErrNoRows = errors.New("no rows in result")
+
+// ErrMultipleRows is returned by Single and MaybeSingle when the query
+// matched more than one row
+var ErrMultipleRows = errors.New("multiple rows in result")
+
+// PostgrestError is an error response from the PostgREST API
+type PostgrestError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Details    string `json:"details"`
+	Hint       string `json:"hint"`
+	Body       string `json:"-"`
+	Attempts   int    `json:"-"`
+}
+
+func (e *PostgrestError) Error() string {
+	// Attempts is only set above 1 when the request was retried
+	if e.Attempts > 1 {
+		return fmt.Sprintf("API error after %d attempts: %s", e.Attempts, e.Body)
+	}
+	return fmt.Sprintf("API error: %s", e.Body)
+}
+
+// singularRowsPattern extracts the row count from a PGRST116 error
+var singularRowsPattern = regexp.MustCompile(`(\d+) rows`)
+
+// Is reports whether the error is ErrNoRows, ErrMultipleRows or ErrMaxAffected
+func (e *PostgrestError) Is(target error) bool {
+	if e.Code == "PGRST124" {
+		return target == ErrMaxAffected
+	}
+	if e.Code != "PGRST116" {
+		return false
+	}
+
+	rows := -1
+	if m := singularRowsPattern.FindStringSubmatch(e.Details); m != nil {
+		rows, _ = strconv.Atoi(m[1])
+	}
+
+	switch target {
+	case ErrNoRows:
+		return rows == 0
+	case ErrMultipleRows:
+		return rows > 1
+	}
+
+	return false
+}
+
+// newPostgrestError parses an error response body
+// Bodies that are not PostgREST JSON errors keep only the status and body
+func newPostgrestError(statusCode int, body []byte) *PostgrestError {
+	e := &PostgrestError{}
+	_ = json.Unmarshal(body, e)
+	e.StatusCode = statusCode
+	e.Body = string(body)
+	return e
+}
@@ -10,33 +10,42 @@ import (
 
 // UserModel represents a user in the database
 type UserModel struct {
-	ID        int         `json:"id"`
+	ID        int         `json:"id" supabase:"pk,readonly"`
 	Name      string      `json:"name"`
 	Email     string      `json:"email"`
-	CreatedAt time.Time   `json:"created_at"`
-	Posts     []PostModel `json:"posts"` // Nested relationship
+	CreatedAt time.Time   `json:"created_at" supabase:"readonly"`
+	Posts     []PostModel `json:"posts" supabase:"rel=posts,fk=posts_user_id_fkey"` // Nested relationship
 }
 
+// TableName returns the table for UserModel
+func (UserModel) TableName() string { return "users" }
+
 // PostModel represents a blog post in the database
 type PostModel struct {
-	ID        int            `json:"id"`
+	ID        int            `json:"id" supabase:"pk,readonly"`
 	Title     string         `json:"title"`
 	Content   string         `json:"content"`
 	UserID    int            `json:"user_id"`
-	CreatedAt time.Time      `json:"created_at"`
-	Comments  []CommentModel `json:"comments"` // Nested relationship
+	CreatedAt time.Time      `json:"created_at" supabase:"readonly"`
+	Comments  []CommentModel `json:"comments" supabase:"rel=comments,fk=comments_post_id_fkey"` // Nested relationship
 }
 
+// TableName returns the table for PostModel
+func (PostModel) TableName() string { return "posts" }
+
 // CommentModel represents a comment on a blog post
 type CommentModel struct {
-	ID        int       `json:"id"`
+	ID        int       `json:"id" supabase:"pk,readonly"`
 	Content   string    `json:"content"`
 	PostID    int       `json:"post_id"`
 	UserID    int       `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
-	User      UserModel `json:"user"` // Nested relationship (commenter)
+	CreatedAt time.Time `json:"created_at" supabase:"readonly"`
+	User      UserModel `json:"user" supabase:"rel=users,fk=comments_user_id_fkey"` // Nested relationship (commenter)
 }
 
+// TableName returns the table for CommentModel
+func (CommentModel) TableName() string { return "comments" }
+
 // JoinsExample demonstrates how to use joins to query related data
 func JoinsExample() {
 	// Initialize the client
@@ -48,11 +57,10 @@ func JoinsExample() {
 
 	// Example 1: Query users with their posts
 	fmt.Println("Example 1: Query users with their posts")
+	// The select list, including the posts embed, comes from the struct tags
 	var users []UserModel
 	err := client.
-		Table("users").
-		Select("id", "name", "email", "created_at").
-		InnerJoin("posts", "id", "user_id").
+		Model(&users).
 		Where("email", "like", "%@example.com").
 		Order("created_at", "desc").
 		Limit(5).
@@ -75,9 +83,7 @@ func JoinsExample() {
 	fmt.Println("\nExample 2: Query posts with comments and comment authors")
 	var posts []PostModel
 	err = client.
-		Table("posts").
-		Select("id", "title", "content", "created_at").
-		InnerJoin("comments", "id", "post_id").
+		Model(&posts).
 		Where("created_at", "gt", "2023-01-01").
 		Order("created_at", "desc").
 		Limit(3).
@@ -0,0 +1,165 @@
+package supabaseorm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Media types for alternative PostgREST response formats
+const (
+	MediaTypeJSON         = "application/json"
+	MediaTypeSingleObject = "application/vnd.pgrst.object+json"
+	MediaTypeGeoJSON      = "application/geo+json"
+	MediaTypePlan         = "application/vnd.pgrst.plan"
+)
+
+// Single returns the result as a single object instead of an array
+// The query fails with ErrNoRows or ErrMultipleRows unless exactly one row matches
+func (q *QueryBuilder) Single() *QueryBuilder {
+	q.accept = MediaTypeSingleObject
+	q.maybeSingle = false
+	return q
+}
+
+// MaybeSingle returns the result as a single object, leaving the
+// destination untouched when no row matches
+// The query fails with ErrMultipleRows if more than one row matches
+func (q *QueryBuilder) MaybeSingle() *QueryBuilder {
+	q.accept = MediaTypeSingleObject
+	q.maybeSingle = true
+	return q
+}
+
+// GeoJSON returns the result as a GeoJSON FeatureCollection
+// The table must have a PostGIS geometry or geography column
+func (q *QueryBuilder) GeoJSON() *QueryBuilder {
+	q.accept = MediaTypeGeoJSON
+	return q
+}
+
+// GeoJSONFeatureCollection is a GeoJSON FeatureCollection
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// GeoJSONFeature is a GeoJSON Feature
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   json.RawMessage        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// ExplainFormat is the output format of an execution plan
+type ExplainFormat string
+
+const (
+	// ExplainJSON returns the plan as JSON, parsed into ExplainPlan.Plan
+	ExplainJSON ExplainFormat = "json"
+	// ExplainText returns the plan as text in ExplainPlan.Text
+	ExplainText ExplainFormat = "text"
+)
+
+// ExplainOptions configures Explain
+type ExplainOptions struct {
+	Analyze  bool
+	Verbose  bool
+	Buffers  bool
+	Settings bool
+	WAL      bool
+	Format   ExplainFormat
+}
+
+// mediaType returns the plan media type for a query returning forType
+func (o ExplainOptions) mediaType(forType string) string {
+	format := o.Format
+	if format == "" {
+		format = ExplainJSON
+	}
+
+	var options []string
+	for _, opt := range []struct {
+		enabled bool
+		name    string
+	}{
+		{o.Analyze, "analyze"},
+		{o.Verbose, "verbose"},
+		{o.Buffers, "buffers"},
+		{o.Settings, "settings"},
+		{o.WAL, "wal"},
+	} {
+		if opt.enabled {
+			options = append(options, opt.name)
+		}
+	}
+
+	mediaType := fmt.Sprintf(`%s+%s; for="%s"`, MediaTypePlan, format, forType)
+	if len(options) > 0 {
+		mediaType += "; options=" + strings.Join(options, "|")
+	}
+
+	return mediaType
+}
+
+// ExplainPlan is the execution plan of a query
+type ExplainPlan struct {
+	Plan          PlanNode `json:"Plan"`
+	PlanningTime  float64  `json:"Planning Time"`
+	ExecutionTime float64  `json:"Execution Time"`
+	// Text holds the plan when ExplainText is requested
+	Text string `json:"-"`
+}
+
+// PlanNode is a node of an execution plan
+// Actual* fields are only set when the plan was analyzed
+type PlanNode struct {
+	NodeType          string     `json:"Node Type"`
+	RelationName      string     `json:"Relation Name"`
+	Schema            string     `json:"Schema"`
+	Alias             string     `json:"Alias"`
+	StartupCost       float64    `json:"Startup Cost"`
+	TotalCost         float64    `json:"Total Cost"`
+	PlanRows          float64    `json:"Plan Rows"`
+	PlanWidth         int        `json:"Plan Width"`
+	ActualStartupTime float64    `json:"Actual Startup Time"`
+	ActualTotalTime   float64    `json:"Actual Total Time"`
+	ActualRows        float64    `json:"Actual Rows"`
+	ActualLoops       float64    `json:"Actual Loops"`
+	Filter            string     `json:"Filter"`
+	IndexName         string     `json:"Index Name"`
+	Output            []string   `json:"Output"`
+	SharedHitBlocks   int64      `json:"Shared Hit Blocks"`
+	SharedReadBlocks  int64      `json:"Shared Read Blocks"`
+	Plans             []PlanNode `json:"Plans"`
+}
+
+// Explain returns the execution plan of the query instead of its results
+// The PostgREST server must have db-plan-enabled set
+func (q *QueryBuilder) Explain(opts ExplainOptions) (*ExplainPlan, error) {
+	forType := q.accept
+	if forType == "" {
+		forType = MediaTypeJSON
+	}
+
+	req := q.newRequest().SetHeader("Accept", opts.mediaType(forType))
+
+	resp, err := q.send(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Format == ExplainText {
+		return &ExplainPlan{Text: resp.String()}, nil
+	}
+
+	var plans []ExplainPlan
+	if err := json.Unmarshal(resp.Body(), &plans); err != nil {
+		return nil, fmt.Errorf("failed to parse explain plan: %w", err)
+	}
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("empty explain plan")
+	}
+
+	return &plans[0], nil
+}
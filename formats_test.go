@@ -0,0 +1,139 @@
+package supabaseorm
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSingle(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusOK, `{"id":1,"name":"Ada"}`))
+	client := server.client()
+
+	var user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := client.Table("users").Where("id", "eq", 1).Single().Get(&user); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if accept := server.requests()[0].header.Get("Accept"); accept != MediaTypeSingleObject {
+		t.Errorf("Expected Accept to be %s, got %s", MediaTypeSingleObject, accept)
+	}
+	if user.Name != "Ada" {
+		t.Errorf("Expected name to be Ada, got %s", user.Name)
+	}
+}
+
+func TestSingleErrors(t *testing.T) {
+	noRows := newCaptureServer(t, respond(http.StatusNotAcceptable,
+		`{"code":"PGRST116","details":"The result contains 0 rows","hint":null,"message":"JSON object requested, multiple (or no) rows returned"}`)).client()
+
+	var row map[string]interface{}
+	err := noRows.Table("users").Single().Get(&row)
+	if !errors.Is(err, ErrNoRows) || errors.Is(err, ErrMultipleRows) {
+		t.Errorf("Expected ErrNoRows, got %v", err)
+	}
+
+	var pgErr *PostgrestError
+	if !errors.As(err, &pgErr) || pgErr.StatusCode != http.StatusNotAcceptable {
+		t.Errorf("Expected PostgrestError with status 406, got %v", err)
+	}
+
+	multiple := newCaptureServer(t, respond(http.StatusNotAcceptable,
+		`{"code":"PGRST116","details":"The result contains 3 rows","hint":null,"message":"JSON object requested, multiple (or no) rows returned"}`)).client()
+
+	err = multiple.Table("users").Single().Get(&row)
+	if !errors.Is(err, ErrMultipleRows) {
+		t.Errorf("Expected ErrMultipleRows, got %v", err)
+	}
+}
+
+func TestMaybeSingle(t *testing.T) {
+	emptyServer := newCaptureServer(t, respond(http.StatusNotAcceptable,
+		`{"code":"PGRST116","details":"The result contains 0 rows","hint":null,"message":"JSON object requested, multiple (or no) rows returned"}`))
+	empty := emptyServer.client()
+
+	row := map[string]interface{}{"untouched": true}
+	if err := empty.Table("users").MaybeSingle().Get(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row["untouched"] != true {
+		t.Error("Expected destination to be untouched when no row matches")
+	}
+	if accept := emptyServer.requests()[0].header.Get("Accept"); accept != MediaTypeSingleObject {
+		t.Errorf("Expected Accept to be %s, got %s", MediaTypeSingleObject, accept)
+	}
+
+	one := newCaptureServer(t, respond(http.StatusOK, `{"id":1}`)).client()
+	var found map[string]interface{}
+	if err := one.Table("users").MaybeSingle().Get(&found); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if found["id"] != float64(1) {
+		t.Errorf("Expected id to be 1, got %v", found["id"])
+	}
+
+	many := newCaptureServer(t, respond(http.StatusNotAcceptable,
+		`{"code":"PGRST116","details":"The result contains 2 rows","hint":null,"message":"JSON object requested, multiple (or no) rows returned"}`)).client()
+	if err := many.Table("users").MaybeSingle().Get(&found); !errors.Is(err, ErrMultipleRows) {
+		t.Errorf("Expected ErrMultipleRows, got %v", err)
+	}
+
+	// Writes matching no row succeed too
+	if err := empty.Table("users").Where("id", "eq", 1).MaybeSingle().Delete(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestGeoJSON(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusOK,
+		`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"name":"HQ"}}]}`))
+	client := server.client()
+
+	var collection GeoJSONFeatureCollection
+	if err := client.Table("places").GeoJSON().Get(&collection); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if accept := server.requests()[0].header.Get("Accept"); accept != MediaTypeGeoJSON {
+		t.Errorf("Expected Accept to be %s, got %s", MediaTypeGeoJSON, accept)
+	}
+	if len(collection.Features) != 1 || collection.Features[0].Properties["name"] != "HQ" {
+		t.Errorf("Unexpected feature collection %+v", collection)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusOK,
+		`[{"Plan":{"Node Type":"Seq Scan","Relation Name":"users","Total Cost":15.5,"Plan Rows":550,"Actual Rows":3},"Planning Time":0.1,"Execution Time":0.2}]`))
+	client := server.client()
+
+	plan, err := client.Table("users").Explain(ExplainOptions{Analyze: true, Buffers: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `application/vnd.pgrst.plan+json; for="application/json"; options=analyze|buffers`
+	if accept := server.requests()[0].header.Get("Accept"); accept != expected {
+		t.Errorf("Expected Accept to be %s, got %s", expected, accept)
+	}
+	if plan.Plan.NodeType != "Seq Scan" || plan.Plan.ActualRows != 3 || plan.ExecutionTime != 0.2 {
+		t.Errorf("Unexpected plan %+v", plan)
+	}
+
+	textServer := newCaptureServer(t, respond(http.StatusOK, "Seq Scan on users  (cost=0.00..15.50 rows=550 width=68)"))
+	plan, err = textServer.client().Table("users").Single().Explain(ExplainOptions{Format: ExplainText})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected = `application/vnd.pgrst.plan+text; for="application/vnd.pgrst.object+json"`
+	if accept := textServer.requests()[0].header.Get("Accept"); accept != expected {
+		t.Errorf("Expected Accept to be %s, got %s", expected, accept)
+	}
+	if plan.Text == "" {
+		t.Error("Expected text plan")
+	}
+}
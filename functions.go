@@ -0,0 +1,197 @@
+package supabaseorm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Functions provides methods for invoking Supabase Edge Functions
+type Functions struct {
+	client *Client
+}
+
+// InvokeOptions configures an Edge Function invocation
+type InvokeOptions struct {
+	// Body is encoded based on its type: []byte and io.Reader are sent as
+	// binary, string as text, url.Values as a form, anything else as JSON
+	Body interface{}
+	// Headers are added to the request and override the client defaults
+	Headers map[string]string
+	// Method defaults to POST
+	Method string
+	// Region pins the invocation to a region, e.g. "us-east-1"
+	Region string
+}
+
+// FunctionResponse is the response from an Edge Function
+type FunctionResponse struct {
+	StatusCode  int
+	Headers     http.Header
+	ContentType string
+	Body        []byte
+}
+
+// FunctionsFetchError is returned when the request could not reach the function
+type FunctionsFetchError struct {
+	Err error
+}
+
+func (e *FunctionsFetchError) Error() string {
+	return fmt.Sprintf("functions fetch error: %v", e.Err)
+}
+
+func (e *FunctionsFetchError) Unwrap() error {
+	return e.Err
+}
+
+// FunctionsRelayError is returned when the Supabase relay failed to invoke the function
+type FunctionsRelayError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *FunctionsRelayError) Error() string {
+	return fmt.Sprintf("functions relay error: %d %s", e.StatusCode, string(e.Body))
+}
+
+// FunctionsHTTPError is returned when the function responded with an error status
+type FunctionsHTTPError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *FunctionsHTTPError) Error() string {
+	return fmt.Sprintf("functions HTTP error: %d %s", e.StatusCode, string(e.Body))
+}
+
+// NewFunctions creates a new Functions instance
+func NewFunctions(client *Client) *Functions {
+	return &Functions{
+		client: client,
+	}
+}
+
+// Invoke calls an Edge Function and reads the whole response
+func (f *Functions) Invoke(ctx context.Context, name string, opts InvokeOptions) (*FunctionResponse, error) {
+	resp, err := f.send(ctx, name, opts, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FunctionResponse{
+		StatusCode:  resp.StatusCode(),
+		Headers:     resp.Header(),
+		ContentType: resp.Header().Get("Content-Type"),
+		Body:        resp.Body(),
+	}, nil
+}
+
+// InvokeStream calls an Edge Function and returns the response body unread,
+// e.g. for server-sent events. The caller must close the returned reader
+func (f *Functions) InvokeStream(ctx context.Context, name string, opts InvokeOptions) (io.ReadCloser, error) {
+	resp, err := f.send(ctx, name, opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.RawBody(), nil
+}
+
+// send builds and executes the invocation request
+func (f *Functions) send(ctx context.Context, name string, opts InvokeOptions, stream bool) (*resty.Response, error) {
+	endpoint := fmt.Sprintf("%s/functions/v1/%s", f.client.baseURL, name)
+
+	req := f.client.httpClient.R().
+		SetContext(ctx).
+		SetDoNotParseResponse(stream)
+
+	if err := setFunctionBody(req, opts.Body); err != nil {
+		return nil, err
+	}
+
+	if opts.Region != "" && opts.Region != "any" {
+		req.SetHeader("x-region", opts.Region)
+	}
+
+	for k, v := range opts.Headers {
+		req.SetHeader(k, v)
+	}
+
+	method := opts.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	resp, err := req.Execute(method, endpoint)
+	if err != nil {
+		return nil, &FunctionsFetchError{Err: err}
+	}
+
+	if resp.StatusCode() < 400 && resp.Header().Get("x-relay-error") != "true" {
+		return resp, nil
+	}
+
+	body := resp.Body()
+	if stream {
+		body, _ = io.ReadAll(resp.RawBody())
+		resp.RawBody().Close()
+	}
+
+	if resp.Header().Get("x-relay-error") == "true" {
+		return nil, &FunctionsRelayError{StatusCode: resp.StatusCode(), Body: body}
+	}
+
+	return nil, &FunctionsHTTPError{StatusCode: resp.StatusCode(), Body: body}
+}
+
+// setFunctionBody encodes body and sets a matching Content-Type
+func setFunctionBody(req *resty.Request, body interface{}) error {
+	switch b := body.(type) {
+	case nil:
+		return nil
+	case []byte:
+		req.SetHeader("Content-Type", "application/octet-stream").SetBody(b)
+	case io.Reader:
+		req.SetHeader("Content-Type", "application/octet-stream").SetBody(b)
+	case string:
+		req.SetHeader("Content-Type", "text/plain").SetBody(b)
+	case url.Values:
+		req.SetHeader("Content-Type", "application/x-www-form-urlencoded").SetBody(b.Encode())
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return fmt.Errorf("failed to encode function body: %w", err)
+		}
+		req.SetHeader("Content-Type", "application/json").SetBody(data)
+	}
+
+	return nil
+}
+
+// Decode decodes the body into v based on the response content type
+// JSON bodies are unmarshaled; *string and *[]byte receive the raw body
+func (r *FunctionResponse) Decode(v interface{}) error {
+	switch dst := v.(type) {
+	case *string:
+		*dst = string(r.Body)
+		return nil
+	case *[]byte:
+		*dst = r.Body
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.ContentType)
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		return json.Unmarshal(r.Body, v)
+	}
+
+	return fmt.Errorf("cannot decode %q response into %T", r.ContentType, v)
+}
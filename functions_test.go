@@ -0,0 +1,119 @@
+package supabaseorm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestInvokeEncodesBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        interface{}
+		contentType string
+		expected    string
+	}{
+		{"json", map[string]string{"name": "world"}, "application/json", `{"name":"world"}`},
+		{"text", "hello", "text/plain", "hello"},
+		{"binary", []byte{0x01, 0x02}, "application/octet-stream", "\x01\x02"},
+		{"form", url.Values{"a": {"1"}}, "application/x-www-form-urlencoded", "a=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newCaptureServer(t, respond(http.StatusOK, ""))
+			if _, err := server.client().Functions().Invoke(context.Background(), "hello", InvokeOptions{Body: tt.body}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			r := server.requests()[0]
+			if r.path != "/functions/v1/hello" {
+				t.Errorf("Expected path to be /functions/v1/hello, got %s", r.path)
+			}
+			if r.header.Get("Content-Type") != tt.contentType {
+				t.Errorf("Expected Content-Type to be %s, got %s", tt.contentType, r.header.Get("Content-Type"))
+			}
+			if r.header.Get("Authorization") != "Bearer test-api-key" {
+				t.Errorf("Expected Authorization to be forwarded, got %s", r.header.Get("Authorization"))
+			}
+			if string(r.body) != tt.expected {
+				t.Errorf("Expected body to be %q, got %q", tt.expected, r.body)
+			}
+		})
+	}
+}
+
+func TestInvokeDecodesResponse(t *testing.T) {
+	server := newCaptureServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{"message":"hi"}`))
+	})
+
+	resp, err := server.client().Functions().Invoke(context.Background(), "hello", InvokeOptions{
+		Method: http.MethodGet,
+		Region: "eu-west-1",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if r := server.requests()[0]; r.method != http.MethodGet || r.header.Get("x-region") != "eu-west-1" {
+		t.Errorf("Expected a GET with x-region eu-west-1, got %s %q", r.method, r.header.Get("x-region"))
+	}
+
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := resp.Decode(&result); err != nil {
+		t.Fatalf("Unexpected error decoding: %v", err)
+	}
+	if result.Message != "hi" {
+		t.Errorf("Expected message to be hi, got %s", result.Message)
+	}
+}
+
+func TestInvokeStream(t *testing.T) {
+	client := newCaptureServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: one\n\ndata: two\n\n"))
+	}).client()
+
+	stream, err := client.Functions().InvokeStream(context.Background(), "events", InvokeOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	data, _ := io.ReadAll(stream)
+	if string(data) != "data: one\n\ndata: two\n\n" {
+		t.Errorf("Unexpected stream contents %q", data)
+	}
+}
+
+func TestInvokeErrors(t *testing.T) {
+	relay := newCaptureServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-relay-error", "true")
+		w.WriteHeader(http.StatusBadGateway)
+	}).client()
+	_, err := relay.Functions().Invoke(context.Background(), "hello", InvokeOptions{})
+	var relayErr *FunctionsRelayError
+	if !errors.As(err, &relayErr) {
+		t.Errorf("Expected FunctionsRelayError, got %v", err)
+	}
+
+	failing := newCaptureServer(t, respond(http.StatusBadRequest, "bad input")).client()
+	_, err = failing.Functions().InvokeStream(context.Background(), "hello", InvokeOptions{})
+	var httpErr *FunctionsHTTPError
+	if !errors.As(err, &httpErr) || string(httpErr.Body) != "bad input" {
+		t.Errorf("Expected FunctionsHTTPError with body, got %v", err)
+	}
+
+	unreachable := New("http://127.0.0.1:1", "test-api-key")
+	_, err = unreachable.Functions().Invoke(context.Background(), "hello", InvokeOptions{})
+	var fetchErr *FunctionsFetchError
+	if !errors.As(err, &fetchErr) {
+		t.Errorf("Expected FunctionsFetchError, got %v", err)
+	}
+}
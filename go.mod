@@ -2,6 +2,20 @@ module github.com/zoc/supabase-orm
 
 go 1.21
 
-require github.com/go-resty/resty/v2 v2.11.0
+require (
+	github.com/go-resty/resty/v2 v2.11.0
+	github.com/gorilla/websocket v1.5.3
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/metric v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/sdk/metric v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+	golang.org/x/time v0.3.0
+)
 
-require golang.org/x/net v0.17.0 // indirect
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	golang.org/x/net v0.17.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+)
@@ -0,0 +1,48 @@
+package supabaseorm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrFullTable is returned by updates and deletes without filters, which
+// would affect every row of the table, unless AllowFullTable is called
+var ErrFullTable = errors.New("update or delete without filters affects the whole table")
+
+// ErrMaxAffected is returned when a write would affect more rows than
+// allowed by MaxAffected; the write is rolled back
+var ErrMaxAffected = errors.New("write affects more rows than allowed")
+
+// AllowFullTable allows an update or delete without filters
+func (q *QueryBuilder) AllowFullTable() *QueryBuilder {
+	q.fullTable = true
+	return q
+}
+
+// MaxAffected makes an update or delete fail with ErrMaxAffected, without
+// changing any row, if it would affect more than n rows
+func (q *QueryBuilder) MaxAffected(n int) *QueryBuilder {
+	q.maxAffected = n
+	return q
+}
+
+// checkFullTable refuses updates and deletes without filters
+// Soft delete and scope filters do not count, as they do not select rows
+func (q *QueryBuilder) checkFullTable() error {
+	if q.method != http.MethodPatch && q.method != http.MethodDelete {
+		return nil
+	}
+	if len(q.filters) == 0 && !q.fullTable {
+		return fmt.Errorf("%w %s: add a filter or call AllowFullTable", ErrFullTable, q.tableName)
+	}
+	return nil
+}
+
+// maxAffectedPreferences returns the Prefer values enforcing MaxAffected
+func (q *QueryBuilder) maxAffectedPreferences() []string {
+	if q.maxAffected <= 0 || (q.method != http.MethodPatch && q.method != http.MethodDelete) {
+		return nil
+	}
+	return []string{"handling=strict", fmt.Sprintf("max-affected=%d", q.maxAffected)}
+}
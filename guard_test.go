@@ -0,0 +1,58 @@
+package supabaseorm
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFullTableGuard(t *testing.T) {
+	server := newCaptureServer(t, softDeleteHandler)
+	client := server.client(WithSoftDelete("posts", "deleted_at"))
+
+	if err := client.Table("users").Delete(); !errors.Is(err, ErrFullTable) {
+		t.Errorf("Expected ErrFullTable for delete, got %v", err)
+	}
+	if err := client.Table("users").Update(map[string]interface{}{"active": false}); !errors.Is(err, ErrFullTable) {
+		t.Errorf("Expected ErrFullTable for update, got %v", err)
+	}
+
+	// The soft delete filter does not count as a filter
+	if err := client.Table("posts").Delete(); !errors.Is(err, ErrFullTable) {
+		t.Errorf("Expected ErrFullTable for soft delete, got %v", err)
+	}
+	if server.count() != 0 {
+		t.Fatalf("Expected no requests, got %d", server.count())
+	}
+
+	if err := client.Table("users").AllowFullTable().Delete(); err != nil {
+		t.Errorf("Expected AllowFullTable to allow the delete, got %v", err)
+	}
+	if server.count() != 1 {
+		t.Errorf("Expected the delete to be sent, got %d requests", server.count())
+	}
+}
+
+func TestMaxAffected(t *testing.T) {
+	var prefer string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefer = r.Header.Get("Prefer")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"PGRST124","message":"Query result exceeds max-affected preference constraint","details":"The query affects 5 rows"}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key")
+
+	q := client.Table("users").Where("active", "eq", false).MaxAffected(1)
+	q.Header("Prefer", "return=representation")
+	err := q.Delete()
+
+	if prefer != "return=representation,handling=strict,max-affected=1" {
+		t.Errorf("Unexpected Prefer header: %q", prefer)
+	}
+	if !errors.Is(err, ErrMaxAffected) {
+		t.Errorf("Expected ErrMaxAffected, got %v", err)
+	}
+}
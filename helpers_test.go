@@ -0,0 +1,87 @@
+package supabaseorm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// capturedRequest is a request received by a captureServer
+type capturedRequest struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   []byte
+}
+
+// jsonBody decodes the body as a JSON object, or returns nil
+func (r capturedRequest) jsonBody() map[string]interface{} {
+	var body map[string]interface{}
+	json.Unmarshal(r.body, &body)
+	return body
+}
+
+// captureServer records the requests it receives before answering them
+type captureServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	received []capturedRequest
+}
+
+// newCaptureServer starts a server that records each request and answers
+// it with handler, which can read the body again
+func newCaptureServer(t *testing.T, handler http.HandlerFunc) *captureServer {
+	s := &captureServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		s.mu.Lock()
+		s.received = append(s.received, capturedRequest{
+			method: r.Method,
+			path:   r.URL.Path,
+			query:  r.URL.Query(),
+			header: r.Header.Clone(),
+			body:   body,
+		})
+		s.mu.Unlock()
+
+		handler(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// client returns a client of the server
+func (s *captureServer) client(options ...ClientOption) *Client {
+	return New(s.URL, "test-api-key", options...)
+}
+
+// requests returns the requests received so far
+func (s *captureServer) requests() []capturedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]capturedRequest(nil), s.received...)
+}
+
+// count returns the number of requests received so far
+func (s *captureServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.received)
+}
+
+// respond returns a handler answering every request with status and body
+func respond(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+}
@@ -0,0 +1,107 @@
+package supabaseorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Models may implement any of the hook interfaces below. The builder calls
+// them around Insert, Update, Delete and reads, for the model itself or for
+// each element of a slice. An error returned by a Before hook aborts the
+// request; an error returned by an After hook is returned by the operation
+//
+//	func (u *User) BeforeInsert(ctx context.Context) error {
+//		u.Email = strings.ToLower(u.Email)
+//		u.CreatedAt = time.Now()
+//		return nil
+//	}
+
+// BeforeInserter is called before a model is inserted
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// AfterInserter is called after a model has been inserted
+type AfterInserter interface {
+	AfterInsert(ctx context.Context) error
+}
+
+// BeforeUpdater is called before a model is sent as an update
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdater is called after a model has been sent as an update
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// BeforeDeleter is called before the model given to Client.Model is deleted
+type BeforeDeleter interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+// AfterDeleter is called after the model given to Client.Model is deleted
+type AfterDeleter interface {
+	AfterDelete(ctx context.Context) error
+}
+
+// AfterFinder is called after a model has been read
+type AfterFinder interface {
+	AfterFind(ctx context.Context) error
+}
+
+// runHook calls hook on data, or on each element of a slice of models,
+// that implements H. A model passed by value whose pointer implements H
+// fails, as the hook would only change a copy
+//
+//	runHook(ctx, data, BeforeInserter.BeforeInsert)
+func runHook[H any](ctx context.Context, data interface{}, hook func(H, context.Context) error) error {
+	hookType := reflect.TypeOf((*H)(nil)).Elem()
+	return eachModel(data, func(model interface{}) error {
+		v := reflect.ValueOf(model)
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil
+		}
+		if h, ok := model.(H); ok {
+			return hook(h, ctx)
+		}
+		if v.IsValid() && v.Kind() != reflect.Ptr && reflect.PointerTo(v.Type()).Implements(hookType) {
+			return fmt.Errorf("%s of %s has a pointer receiver: pass a pointer to the model", hookType.Name(), v.Type())
+		}
+		return nil
+	})
+}
+
+// eachModel calls fn with data or, for a (pointer to a) slice, with a
+// pointer to each element so that hooks with pointer receivers can modify it
+func eachModel(data interface{}, fn func(model interface{}) error) error {
+	if data == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		if k := v.Elem().Kind(); k == reflect.Slice || k == reflect.Array {
+			v = v.Elem()
+		}
+	}
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fn(data)
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		if elem.Kind() != reflect.Ptr && elem.Kind() != reflect.Interface && elem.CanAddr() {
+			elem = elem.Addr()
+		}
+
+		if err := fn(elem.Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
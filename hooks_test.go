@@ -0,0 +1,168 @@
+package supabaseorm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type hookUser struct {
+	ID    int    `json:"id" supabase:"pk,readonly"`
+	Email string `json:"email"`
+
+	calls []string
+}
+
+func (hookUser) TableName() string { return "users" }
+
+func (u *hookUser) BeforeInsert(ctx context.Context) error {
+	if u.Email == "" {
+		return errors.New("email is required")
+	}
+	u.Email = strings.ToLower(u.Email)
+	u.calls = append(u.calls, "BeforeInsert")
+	return nil
+}
+
+func (u *hookUser) AfterInsert(ctx context.Context) error {
+	u.calls = append(u.calls, "AfterInsert")
+	return nil
+}
+
+func (u *hookUser) BeforeUpdate(ctx context.Context) error {
+	u.calls = append(u.calls, "BeforeUpdate")
+	return nil
+}
+
+func (u *hookUser) AfterFind(ctx context.Context) error {
+	u.calls = append(u.calls, "AfterFind")
+	return nil
+}
+
+func (u *hookUser) BeforeDelete(ctx context.Context) error {
+	if u.ID == 1 {
+		return errors.New("user 1 cannot be deleted")
+	}
+	return nil
+}
+
+func TestInsertHooks(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusCreated, ""))
+	client := server.client()
+
+	user := hookUser{Email: "Ada@Example.com"}
+	if err := client.Table("users").Insert(&user); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if email := server.requests()[0].jsonBody()["email"]; email != "ada@example.com" {
+		t.Errorf("Expected BeforeInsert to normalize the email, got %v", email)
+	}
+	if strings.Join(user.calls, ",") != "BeforeInsert,AfterInsert" {
+		t.Errorf("Expected insert hooks, got %v", user.calls)
+	}
+
+	// A failing hook aborts the request
+	if err := client.Table("users").Insert(&hookUser{}); err == nil || err.Error() != "email is required" {
+		t.Errorf("Expected the hook error, got %v", err)
+	}
+	if server.count() != 1 {
+		t.Errorf("Expected no request after a failing hook, got %d", server.count())
+	}
+}
+
+func TestHooksOnValues(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusCreated, ""))
+	client := server.client()
+
+	// Pointer hooks cannot run on a copy, so a model passed by value fails
+	err := client.Table("users").Insert(hookUser{Email: "Ada@Example.com"})
+	if err == nil || !strings.Contains(err.Error(), "BeforeInserter of supabaseorm.hookUser has a pointer receiver") {
+		t.Errorf("Expected a pointer receiver error, got %v", err)
+	}
+	if server.count() != 0 {
+		t.Errorf("Expected no request for a model passed by value, got %d", server.count())
+	}
+
+	// Elements of a slice passed by value are changed in place
+	users := []hookUser{{Email: "Ada@Example.com"}}
+	if err := client.Table("users").Insert(users); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if users[0].Email != "ada@example.com" || server.count() != 1 {
+		t.Errorf("Expected the element to be normalized and sent, got %+v", users[0])
+	}
+}
+
+func TestFindAndUpdateHooks(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusOK, `[{"id":2,"email":"a@example.com"},{"id":3,"email":"b@example.com"}]`))
+	client := server.client()
+
+	var users []hookUser
+	if err := client.Table("users").Get(&users); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, u := range users {
+		if strings.Join(u.calls, ",") != "AfterFind" {
+			t.Errorf("Expected AfterFind on each row, got %v", u.calls)
+		}
+	}
+
+	user := &users[0]
+	if err := client.Table("users").Where("id", "eq", 2).UpdateFields(user, "email"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(user.calls, ",") != "AfterFind,BeforeUpdate" {
+		t.Errorf("Expected BeforeUpdate, got %v", user.calls)
+	}
+}
+
+func TestDeleteHooks(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusNoContent, ""))
+	client := server.client()
+
+	if err := client.Destroy(context.Background(), &hookUser{ID: 1}); err == nil {
+		t.Error("Expected BeforeDelete to abort the delete")
+	}
+	if err := client.Destroy(context.Background(), &hookUser{ID: 2}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if requests := server.requests(); len(requests) != 1 || requests[0].query.Get("id") != "eq.2" {
+		t.Errorf("Expected only user 2 to be deleted, got %+v", requests)
+	}
+}
+
+func TestInsertManyHooks(t *testing.T) {
+	handler := &bulkServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key")
+
+	users := []hookUser{{Email: "A@x.com"}, {Email: "B@x.com"}, {Email: "C@x.com"}}
+	if _, err := client.Table("users").InsertMany(users, BatchSize(2)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, u := range users {
+		if strings.Join(u.calls, ",") != "BeforeInsert,AfterInsert" {
+			t.Errorf("Expected insert hooks on each row, got %v", u.calls)
+		}
+	}
+	if handler.batches[1][0]["email"] != "c@x.com" {
+		t.Errorf("Expected rows to be normalized before sending, got %v", handler.batches[1])
+	}
+
+	// A failing hook on any row aborts the whole insert
+	users = append(users, hookUser{})
+	handler.batches = nil
+	if _, err := client.Table("users").InsertMany(users); err == nil {
+		t.Error("Expected the hook error")
+	}
+	if len(handler.batches) != 0 {
+		t.Errorf("Expected no batches after a failing hook, got %d", len(handler.batches))
+	}
+}
@@ -0,0 +1,262 @@
+package supabaseorm
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces secrets in logs
+const redacted = "[REDACTED]"
+
+// WithLogger logs every request of the client to logger: the method, the
+// decoded URL, the Prefer header, the status, the duration and the response
+// size. Successful requests are logged at level, failed ones at least at
+// slog.LevelWarn. Keys and tokens are never logged
+func WithLogger(logger *slog.Logger, level slog.Level) ClientOption {
+	return func(c *Client) {
+		c.logging().logger = logger
+		c.logging().level = level
+	}
+}
+
+// WithBodyLogging also logs the headers and up to limit bytes of the
+// request and response bodies, with passwords and tokens redacted
+func WithBodyLogging(limit int) ClientOption {
+	return func(c *Client) {
+		c.logging().bodyLimit = limit
+	}
+}
+
+// logConfig holds the logging options of a client
+type logConfig struct {
+	logger    *slog.Logger
+	level     slog.Level
+	bodyLimit int
+}
+
+// logging returns the client's logging options, creating them on first use
+func (c *Client) logging() *logConfig {
+	if c.log == nil {
+		c.log = &logConfig{}
+	}
+	return c.log
+}
+
+// sensitiveHeaders are replaced in logged headers
+var sensitiveHeaders = map[string]bool{
+	"Apikey":        true,
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+}
+
+// sensitiveParams are replaced in logged URLs
+var sensitiveParams = map[string]bool{
+	"apikey":        true,
+	"access_token":  true,
+	"refresh_token": true,
+	"token":         true,
+}
+
+// sensitiveFields matches JSON string fields holding secrets, including a
+// value cut short by the body limit
+var sensitiveFields = regexp.MustCompile(`("(?:password|access_token|refresh_token|provider_token|provider_refresh_token|token|apikey|secret)"\s*:\s*)"(?:[^"\\]|\\.)*("|$)`)
+
+// redactBody removes secrets from a JSON body
+func redactBody(body string) string {
+	return sensitiveFields.ReplaceAllString(body, `$1"`+redacted+`"`)
+}
+
+// redactURL returns the path and decoded query of u, with secrets removed
+func redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+
+	parts := strings.Split(u.RawQuery, "&")
+	for i, part := range parts {
+		key, value, _ := strings.Cut(part, "=")
+		if k, err := url.QueryUnescape(key); err == nil {
+			key = k
+		}
+		if v, err := url.QueryUnescape(value); err == nil {
+			value = v
+		}
+		if sensitiveParams[strings.ToLower(key)] {
+			value = redacted
+		}
+		parts[i] = key + "=" + value
+	}
+
+	return u.Path + "?" + strings.Join(parts, "&")
+}
+
+// redactHeaders returns the headers of a request or response, with secrets removed
+func redactHeaders(name string, header http.Header) slog.Attr {
+	attrs := make([]any, 0, len(header))
+	for key, values := range header {
+		value := strings.Join(values, ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(key)] {
+			value = redacted
+		}
+		attrs = append(attrs, slog.String(key, value))
+	}
+	return slog.Group(name, attrs...)
+}
+
+// logTransport logs requests
+type logTransport struct {
+	next   http.RoundTripper
+	config logConfig
+}
+
+func (t *logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !t.config.logger.Enabled(ctx, t.config.level) && !t.config.logger.Enabled(ctx, slog.LevelWarn) {
+		return t.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", redactURL(req.URL)),
+	}
+	if info := requestOperation(req); info.table != "" {
+		attrs = append(attrs, slog.String("operation", info.operation), slog.String("table", info.table))
+	} else {
+		attrs = append(attrs, slog.String("operation", info.operation))
+	}
+	if prefer := req.Header.Get("Prefer"); prefer != "" {
+		attrs = append(attrs, slog.String("prefer", prefer))
+	}
+
+	if t.config.bodyLimit > 0 {
+		attrs = append(attrs, redactHeaders("request_headers", req.Header))
+		if body := t.requestBody(req); body != "" {
+			attrs = append(attrs, slog.String("request_body", body))
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		attrs = append(attrs, slog.Duration("duration", time.Since(start)), slog.String("error", err.Error()))
+		attrs = append(attrs, attemptsAttr(req)...)
+		t.config.logger.LogAttrs(ctx, t.failureLevel(), "supabase request failed", attrs...)
+		return nil, err
+	}
+
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+
+	level := t.config.level
+	if resp.StatusCode >= 400 {
+		level = t.failureLevel()
+	}
+
+	if resp.Body == nil {
+		resp.Body = http.NoBody
+	}
+
+	// The response is logged once its body has been read and closed, so that
+	// its size is known
+	body := &loggedBody{ReadCloser: resp.Body, limit: t.config.bodyLimit}
+	body.done = func() {
+		attrs := append(attrs,
+			slog.Duration("duration", time.Since(start)),
+			slog.Int64("response_size", body.size))
+		attrs = append(attrs, attemptsAttr(req)...)
+		if t.config.bodyLimit > 0 {
+			captured := redactBody(body.captured.String())
+			if body.size > int64(body.captured.Len()) {
+				captured += truncated
+			}
+			attrs = append(attrs, redactHeaders("response_headers", resp.Header), slog.String("response_body", captured))
+		}
+		t.config.logger.LogAttrs(ctx, level, "supabase request", attrs...)
+	}
+	resp.Body = body
+
+	return resp, nil
+}
+
+// attemptsAttr returns the number of attempts of a retried request
+func attemptsAttr(req *http.Request) []slog.Attr {
+	if attempts, ok := req.Context().Value(attemptsKey{}).(*int); ok && *attempts > 1 {
+		return []slog.Attr{slog.Int("attempts", *attempts)}
+	}
+	return nil
+}
+
+// failureLevel is the level of failed requests: the configured level, but
+// at least warn
+func (t *logTransport) failureLevel() slog.Level {
+	if t.config.level > slog.LevelWarn {
+		return t.config.level
+	}
+	return slog.LevelWarn
+}
+
+// requestBody returns the redacted, truncated request body
+func (t *logTransport) requestBody(req *http.Request) string {
+	if req.GetBody == nil || req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+
+	return truncate(redactBody(string(data)), t.config.bodyLimit)
+}
+
+// truncated marks a body cut at the limit
+const truncated = "...(truncated)"
+
+// truncate cuts s to limit bytes
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + truncated
+}
+
+// loggedBody counts the bytes read from a response body, keeps the first
+// limit bytes, and calls done when it is closed
+type loggedBody struct {
+	io.ReadCloser
+	limit    int
+	size     int64
+	captured strings.Builder
+	done     func()
+	once     sync.Once
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if room := b.limit - b.captured.Len(); room > 0 {
+		if room > n {
+			room = n
+		}
+		b.captured.Write(p[:room])
+	}
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
@@ -0,0 +1,175 @@
+package supabaseorm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// logRecords decodes the JSON log records written to buf
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code":"23503","message":"foreign key violation"}`))
+			return
+		}
+		w.Write([]byte(`[{"id":1},{"id":2}]`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := New(server.URL, "secret-api-key", WithLogger(logger, slog.LevelDebug))
+
+	var rows []map[string]interface{}
+	if err := client.Table("users").Select("id", "name").Where("name", "eq", "Jane Doe").Get(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	client.Table("users").Where("id", "eq", 1).Delete()
+	client.Table("users").Count()
+
+	if strings.Contains(buf.String(), "secret-api-key") {
+		t.Errorf("API key was logged: %s", buf.String())
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+
+	get := records[0]
+	if get["level"] != "DEBUG" || get["method"] != "GET" || get["operation"] != "select" || get["table"] != "users" {
+		t.Errorf("Unexpected record %v", get)
+	}
+	if get["url"] != "/rest/v1/users?name=eq.Jane Doe&select=id,name" {
+		t.Errorf("Expected decoded URL, got %v", get["url"])
+	}
+	if get["status"] != float64(200) || get["response_size"] != float64(len(`[{"id":1},{"id":2}]`)) {
+		t.Errorf("Unexpected status or size in %v", get)
+	}
+	if _, ok := get["duration"]; !ok {
+		t.Error("Expected a duration")
+	}
+	if _, ok := get["request_body"]; ok {
+		t.Error("Bodies should not be logged by default")
+	}
+
+	del := records[1]
+	if del["level"] != "WARN" || del["status"] != float64(http.StatusConflict) {
+		t.Errorf("Expected a warning for the failed delete, got %v", del)
+	}
+
+	if count := records[2]; count["prefer"] != "count=exact" {
+		t.Errorf("Expected the Prefer header, got %v", count["prefer"])
+	}
+}
+
+func TestLoggerRedactsBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"jwt-access","refresh_token":"jwt-refresh","user":{"id":"42","email":"jane@example.com"}}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	client := New(server.URL, "secret-api-key", WithLogger(logger, slog.LevelInfo), WithBodyLogging(4096))
+
+	_, err := client.Auth().SignInWithPassword(context.Background(), SignInRequest{Email: "jane@example.com", Password: "hunter2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Auth().RefreshToken(context.Background(), RefreshTokenRequest{RefreshToken: "jwt-refresh"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, secret := range []string{"secret-api-key", "hunter2", "jwt-access", "jwt-refresh"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("%q was logged: %s", secret, buf.String())
+		}
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	signIn := records[0]
+	if signIn["operation"] != "auth.token" || signIn["url"] != "/auth/v1/token?grant_type=password" {
+		t.Errorf("Unexpected record %v", signIn)
+	}
+	if body, _ := signIn["request_body"].(string); !strings.Contains(body, "jane@example.com") || !strings.Contains(body, `"password":"[REDACTED]"`) {
+		t.Errorf("Unexpected request body %q", body)
+	}
+	if body, _ := signIn["response_body"].(string); !strings.Contains(body, `"access_token":"[REDACTED]"`) || !strings.Contains(body, "jane@example.com") {
+		t.Errorf("Unexpected response body %q", body)
+	}
+	headers, _ := signIn["request_headers"].(map[string]interface{})
+	if headers["Apikey"] != redacted || headers["Authorization"] != redacted {
+		t.Errorf("Expected redacted headers, got %v", headers)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"password":"hunter2"}`, `{"password":"[REDACTED]"}`},
+		{`{"password" : "a \"quoted\" pass", "email":"a@b.c"}`, `{"password" : "[REDACTED]", "email":"a@b.c"}`},
+		// A token cut short by the body limit
+		{`{"refresh_token":"abcdef`, `{"refresh_token":"[REDACTED]"`},
+		{`{"name":"token"}`, `{"name":"token"}`},
+	}
+
+	for _, tt := range tests {
+		if got := redactBody(tt.body); got != tt.want {
+			t.Errorf("redactBody(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestLoggerTruncatesBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":1,"name":"a long name that will be cut"}]`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	client := New(server.URL, "key", WithLogger(logger, slog.LevelInfo), WithBodyLogging(10))
+
+	var rows []map[string]interface{}
+	if err := client.Table("users").Get(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("Logging changed the response: %v", rows)
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 1 || records[0]["response_body"] != `[{"id":1,"...(truncated)` {
+		t.Errorf("Expected the first 10 bytes of the body, got %v", records)
+	}
+}
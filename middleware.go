@@ -0,0 +1,210 @@
+package supabaseorm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Request describes a table or RPC query before it is encoded and sent
+//
+// Middleware may change the context, query parameters, headers and body.
+// Filters only describes the query: changing it has no effect, change
+// Query instead
+type Request struct {
+	Context context.Context
+	// Operation is select, count, insert, upsert, update, delete or rpc
+	Operation string
+	Table     string
+	// Function is the database function called by RPC and Raw queries
+	Function string
+	Schema   string
+	Method   string
+	URL      string
+	// Filters are the query's filters, including those of soft delete and scopes
+	Filters []Filter
+	Query   url.Values
+	Header  http.Header
+	// Body is the value encoded as the JSON body, nil for reads
+	Body interface{}
+}
+
+// Filter describes a filter of a query
+type Filter struct {
+	Column   string
+	Operator string
+	Value    interface{}
+	// Or is set when the filter was added with OrWhere
+	Or bool
+	// Condition is the PostgREST condition, e.g. age.gt.18
+	Condition string
+}
+
+// RoundTripper sends a Request. Network errors are returned as errors,
+// error statuses as a Response whose Error is a *PostgrestError
+type RoundTripper interface {
+	RoundTrip(req *Request) (*Response, error)
+}
+
+// RoundTripperFunc adapts a function to a RoundTripper
+type RoundTripperFunc func(req *Request) (*Response, error)
+
+// RoundTrip calls f(req)
+func (f RoundTripperFunc) RoundTrip(req *Request) (*Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the sending of table and RPC queries, e.g. for auditing,
+// header injection, caching or fault injection
+//
+//	audit := func(next supabaseorm.RoundTripper) supabaseorm.RoundTripper {
+//		return supabaseorm.RoundTripperFunc(func(req *supabaseorm.Request) (*supabaseorm.Response, error) {
+//			resp, err := next.RoundTrip(req)
+//			log.Printf("%s %s: %v", req.Operation, req.Table, err)
+//			return resp, err
+//		})
+//	}
+//
+// A middleware may return a Response without calling next. The Body of
+// streamed responses is nil. Auth, realtime and function requests do not go
+// through middleware
+type Middleware func(next RoundTripper) RoundTripper
+
+// WithMiddleware adds middleware to the client. The first one added is the
+// outermost
+func WithMiddleware(middleware ...Middleware) ClientOption {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// errNoResponse is returned when a middleware returns neither a response nor an error
+var errNoResponse = errors.New("middleware returned no response")
+
+// requestFilters describes the filters the query is sent with
+func (q *QueryBuilder) requestFilters() []Filter {
+	sources := [][]filter{q.filters}
+	if f, ok := q.softDeleteFilter(); ok {
+		sources = append(sources, []filter{f})
+	}
+	sources = append(sources, q.scopeFilters()...)
+
+	var filters []Filter
+	for _, source := range sources {
+		for _, f := range source {
+			described := Filter{Or: f.isOr, Condition: f.condition()}
+			if !f.isComplex {
+				described.Column = f.column
+				described.Operator = normalizeOperator(f.operator)
+				described.Value = f.value
+			}
+			filters = append(filters, described)
+		}
+	}
+	return filters
+}
+
+// roundTrip sends req through the client's middleware, then req is sent
+// with what the middleware left in the descriptor
+func (q *QueryBuilder) roundTrip(req *resty.Request, body interface{}, attempts *int) (*resty.Response, error) {
+	descriptor := &Request{
+		Context:   req.Context(),
+		Operation: q.operation(),
+		Table:     q.tableName,
+		Schema:    q.schema,
+		Method:    q.method,
+		URL:       q.endpoint(),
+		Query:     req.QueryParam,
+		Header:    req.Header,
+		Body:      body,
+	}
+	switch {
+	case q.rawQuery != "":
+		descriptor.Table = ""
+		descriptor.Function = "execute_sql"
+	case q.rpc != "":
+		descriptor.Function = q.rpc
+		descriptor.Filters = q.requestFilters()
+	default:
+		descriptor.Filters = q.requestFilters()
+	}
+
+	var transport RoundTripper = RoundTripperFunc(func(r *Request) (*Response, error) {
+		return transmit(req, r)
+	})
+	for i := len(q.client.middleware) - 1; i >= 0; i-- {
+		transport = q.client.middleware[i](transport)
+	}
+
+	resp, err := transport.RoundTrip(descriptor)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errNoResponse
+	}
+
+	if resp.IsError() {
+		apiErr, ok := resp.Error.(*PostgrestError)
+		if !ok {
+			if resp.Error != nil {
+				return nil, resp.Error
+			}
+			apiErr = newPostgrestError(resp.StatusCode, bytes.TrimSpace(resp.Body))
+		}
+		apiErr.Attempts = *attempts
+		return nil, apiErr
+	}
+	return resp.restyResponse(req), nil
+}
+
+// transmit sends the request described by r
+func transmit(req *resty.Request, r *Request) (*Response, error) {
+	req.SetContext(r.Context)
+	req.QueryParam = r.Query
+	req.Header = r.Header
+	if r.Body != nil {
+		req.SetBody(r.Body)
+	}
+
+	resp, err := req.Execute(r.Method, r.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	response := NewResponse(resp, nil)
+	response.raw = resp
+	if resp.IsError() {
+		apiErr := apiError(resp)
+		response.Error = apiErr
+		// Streamed error bodies are read by apiError
+		response.Body = []byte(apiErr.Body)
+	}
+	return response, nil
+}
+
+// restyResponse returns the response as a resty response, applying the
+// changes made by middleware
+func (r *Response) restyResponse(req *resty.Request) *resty.Response {
+	raw := r.raw
+	if raw == nil || raw.RawResponse == nil {
+		raw = &resty.Response{Request: req, RawResponse: &http.Response{Header: http.Header{}}}
+	}
+
+	raw.RawResponse.StatusCode = r.StatusCode
+	raw.RawResponse.Status = http.StatusText(r.StatusCode)
+	for key, value := range r.Headers {
+		raw.RawResponse.Header.Set(key, value)
+	}
+
+	if r.Body != nil && (r.raw == nil || !bytes.Equal(r.Body, raw.Body())) {
+		raw.SetBody(r.Body)
+		raw.RawResponse.Body = io.NopCloser(bytes.NewReader(r.Body))
+	}
+	return raw
+}
@@ -0,0 +1,176 @@
+package supabaseorm
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var header string
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Request-Source")
+		query = r.URL.Query().Get("deleted")
+		w.Write([]byte(`[{"id":1}]`))
+	}))
+	defer server.Close()
+
+	var order []string
+	var seen *Request
+	var status int
+	trace := func(name string) Middleware {
+		return func(next RoundTripper) RoundTripper {
+			return RoundTripperFunc(func(req *Request) (*Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	audit := func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(req *Request) (*Response, error) {
+			seen = req
+			req.Header.Set("X-Request-Source", "audit")
+			req.Query.Set("deleted", "is.false")
+			resp, err := next.RoundTrip(req)
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			return resp, err
+		})
+	}
+
+	client := New(server.URL, "key", WithMiddleware(trace("outer"), audit, trace("inner")), WithSchema("api"))
+
+	var rows []map[string]interface{}
+	err := client.Table("users").Where("age", "gt", 18).OrWhere("role", "eq", "admin").Get(&rows)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Errorf("Unexpected middleware order %v", order)
+	}
+	if seen.Operation != "select" || seen.Table != "users" || seen.Schema != "api" || seen.Method != http.MethodGet {
+		t.Errorf("Unexpected request %+v", seen)
+	}
+	if len(seen.Filters) != 2 || seen.Filters[0].Column != "age" || seen.Filters[0].Operator != "gt" ||
+		seen.Filters[0].Value != 18 || seen.Filters[0].Condition != "age.gt.18" || !seen.Filters[1].Or {
+		t.Errorf("Unexpected filters %+v", seen.Filters)
+	}
+	if header != "audit" || query != "is.false" {
+		t.Errorf("Middleware changes were not sent: header %q, query %q", header, query)
+	}
+	if status != http.StatusOK || len(rows) != 1 {
+		t.Errorf("Unexpected response %d %v", status, rows)
+	}
+}
+
+func TestMiddlewareBody(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := make([]byte, r.ContentLength)
+		r.Body.Read(data)
+		body = string(data)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	stamp := func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(req *Request) (*Response, error) {
+			if req.Operation == "insert" {
+				row := req.Body.(map[string]interface{})
+				row["source"] = "api"
+			}
+			return next.RoundTrip(req)
+		})
+	}
+
+	client := New(server.URL, "key", WithMiddleware(stamp))
+	if err := client.Table("users").Insert(map[string]interface{}{"name": "Jane"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if body != `{"name":"Jane","source":"api"}` {
+		t.Errorf("Unexpected body %s", body)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	cached := func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(req *Request) (*Response, error) {
+			switch req.Table {
+			case "cached":
+				return &Response{StatusCode: http.StatusOK, Headers: map[string]string{"Content-Range": "0-0/1"}, Body: []byte(`[{"id":7}]`)}, nil
+			case "unavailable":
+				return &Response{StatusCode: http.StatusServiceUnavailable, Body: []byte(`{"message":"injected"}`)}, nil
+			case "broken":
+				return nil, errors.New("injected failure")
+			}
+			return next.RoundTrip(req)
+		})
+	}
+	client := New(server.URL, "key", WithMiddleware(cached))
+
+	var rows []map[string]interface{}
+	if err := client.Table("cached").Get(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["id"] != float64(7) {
+		t.Errorf("Expected the cached rows, got %v", rows)
+	}
+	if count, err := client.Table("cached").Count(); err != nil || count != 1 {
+		t.Errorf("Expected the cached count, got %d, %v", count, err)
+	}
+
+	err := client.Table("unavailable").Get(&rows)
+	var apiErr *PostgrestError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Message != "injected" {
+		t.Errorf("Expected the injected API error, got %v", err)
+	}
+
+	if err := client.Table("broken").Get(&rows); err == nil || err.Error() != "injected failure" {
+		t.Errorf("Expected the injected error, got %v", err)
+	}
+
+	if requests != 0 {
+		t.Errorf("Expected no request to reach the server, got %d", requests)
+	}
+}
+
+func TestMiddlewareSeesAPIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"code":"23505","message":"duplicate key"}`))
+	}))
+	defer server.Close()
+
+	var seen error
+	observe := func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(req *Request) (*Response, error) {
+			resp, err := next.RoundTrip(req)
+			if err == nil {
+				seen = resp.Error
+			}
+			return resp, err
+		})
+	}
+	client := New(server.URL, "key", WithMiddleware(observe))
+
+	err := client.Table("users").Insert(map[string]interface{}{"id": 1})
+	var apiErr *PostgrestError
+	if !errors.As(seen, &apiErr) || apiErr.Code != "23505" {
+		t.Errorf("Expected the middleware to see the API error, got %v", seen)
+	}
+	if !errors.As(err, &apiErr) || apiErr.Code != "23505" {
+		t.Errorf("Expected the API error, got %v", err)
+	}
+}
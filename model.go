@@ -0,0 +1,485 @@
+package supabaseorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TableNamer is implemented by models that know their table name
+type TableNamer interface {
+	TableName() string
+}
+
+// ModelInfo is the table mapping of a struct type, derived from its tags
+//
+//	type User struct {
+//		ID    int    `json:"id" supabase:"column=id,pk,readonly"`
+//		Name  string `json:"name"`
+//		Posts []Post `json:"posts" supabase:"rel=posts,fk=posts_user_id_fkey"`
+//	}
+//
+// Options of the supabase tag:
+//   - column=<name>: the column name, defaulting to the db tag or json name
+//   - pk: the primary key, used by Find; not sent on insert when zero, so
+//     the database generates it
+//   - readonly: never sent on insert or update
+//   - omitempty: not sent when zero, as does json's omitempty option
+//   - default: not sent on insert when zero, so the column default applies
+//   - softdelete: the deletion timestamp; Delete sets it instead of deleting
+//   - rel=<table>: an embedded relationship, loaded with the parent
+//   - fk=<constraint>: the foreign key used to disambiguate the relationship
+//   - "-": the field is ignored
+//
+// A db tag, as used by sqlx, also names the column, and db:"-" ignores the
+// field. Fields of embedded structs are promoted as with encoding/json
+type ModelInfo struct {
+	Type       reflect.Type
+	Table      string
+	Fields     []*FieldInfo
+	PrimaryKey *FieldInfo
+	SoftDelete *FieldInfo
+	Relations  []*RelationInfo
+
+	// tagged is set when a field has a supabase or db tag
+	tagged bool
+}
+
+// FieldInfo describes a column of a model
+type FieldInfo struct {
+	Name       string
+	JSONName   string
+	Column     string
+	Index      []int
+	PK         bool
+	ReadOnly   bool
+	OmitEmpty  bool
+	Default    bool
+	SoftDelete bool
+}
+
+// RelationInfo describes an embedded relationship of a model
+type RelationInfo struct {
+	Name       string
+	JSONName   string
+	Table      string
+	ForeignKey string
+	Index      []int
+	Type       reflect.Type
+}
+
+var (
+	modelCache  sync.Map // reflect.Type -> *ModelInfo
+	modelTables sync.Map // reflect.Type -> string
+)
+
+// RegisterModel sets the table of a model type that does not implement TableNamer
+func RegisterModel(model interface{}, table string) {
+	modelTables.Store(modelType(model), table)
+	modelCache.Delete(modelType(model))
+}
+
+// ModelOf returns the mapping of a model, which may be a struct, a pointer
+// to a struct or a (pointer to a) slice of structs
+func ModelOf(model interface{}) (*ModelInfo, error) {
+	if model == nil {
+		return nil, fmt.Errorf("model must be a struct, got nil")
+	}
+
+	t := modelType(model)
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model must be a struct, got %s", t)
+	}
+
+	if info, ok := modelCache.Load(t); ok {
+		return info.(*ModelInfo), nil
+	}
+
+	info, err := parseModel(t)
+	if err != nil {
+		return nil, err
+	}
+
+	modelCache.Store(t, info)
+	return info, nil
+}
+
+// modelType dereferences pointers and slices down to the element type
+func modelType(model interface{}) reflect.Type {
+	t, ok := model.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(model)
+	}
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t
+}
+
+// parseModel reads the struct tags of t
+func parseModel(t reflect.Type) (*ModelInfo, error) {
+	info := &ModelInfo{Type: t}
+
+	if table, ok := modelTables.Load(t); ok {
+		info.Table = table.(string)
+	} else if namer, ok := reflect.New(t).Interface().(TableNamer); ok {
+		info.Table = namer.TableName()
+	}
+
+	info.addFields(t, nil, map[reflect.Type]bool{})
+	info.Fields = dominantFields(info.Fields)
+
+	for _, field := range info.Fields {
+		if field.PK {
+			if info.PrimaryKey != nil {
+				return nil, fmt.Errorf("model %s has more than one primary key", t)
+			}
+			info.PrimaryKey = field
+		}
+
+		if field.SoftDelete {
+			if info.SoftDelete != nil {
+				return nil, fmt.Errorf("model %s has more than one soft delete column", t)
+			}
+			info.SoftDelete = field
+		}
+	}
+
+	return info, nil
+}
+
+// addFields adds the fields of t, found at index in the model. Like
+// encoding/json, the fields of embedded structs without a json name are
+// promoted into the model
+func (m *ModelInfo) addFields(t reflect.Type, index []int, visiting map[reflect.Type]bool) {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		sf.Index = append(append([]int(nil), index...), i)
+
+		tag, hasTag := sf.Tag.Lookup("supabase")
+		dbTag, hasDBTag := sf.Tag.Lookup("db")
+		if tag == "-" || dbTag == "-" {
+			continue
+		}
+		if hasTag || hasDBTag {
+			m.tagged = true
+		}
+
+		if sf.Anonymous {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			if embedded.Kind() == reflect.Struct && jsonName == "" && !hasTag && !visiting[embedded] {
+				m.addFields(embedded, sf.Index, visiting)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		jsonName, skip := jsonFieldName(sf)
+		if skip && !hasTag && !hasDBTag {
+			continue
+		}
+
+		options := parseTagOptions(tag)
+
+		if table, ok := options["rel"]; ok {
+			m.Relations = append(m.Relations, &RelationInfo{
+				Name:       sf.Name,
+				JSONName:   jsonName,
+				Table:      table,
+				ForeignKey: options["fk"],
+				Index:      sf.Index,
+				Type:       modelType(sf.Type),
+			})
+			continue
+		}
+
+		field := &FieldInfo{
+			Name:     sf.Name,
+			JSONName: jsonName,
+			Column:   jsonName,
+			Index:    sf.Index,
+		}
+		if column, _, _ := strings.Cut(dbTag, ","); column != "" {
+			field.Column = column
+		}
+		if column := options["column"]; column != "" {
+			field.Column = column
+		}
+		_, field.PK = options["pk"]
+		_, field.ReadOnly = options["readonly"]
+		_, field.OmitEmpty = options["omitempty"]
+		if _, opts, _ := strings.Cut(sf.Tag.Get("json"), ","); strings.Contains(","+opts+",", ",omitempty,") {
+			field.OmitEmpty = true
+		}
+		_, field.Default = options["default"]
+		_, field.SoftDelete = options["softdelete"]
+
+		m.Fields = append(m.Fields, field)
+	}
+}
+
+// dominantFields drops promoted fields hidden by another field with the same
+// json name, following encoding/json: the shallowest field wins, and fields
+// at the same depth hide each other
+func dominantFields(fields []*FieldInfo) []*FieldInfo {
+	depths := make(map[string][]int, len(fields))
+	for _, f := range fields {
+		depths[f.JSONName] = append(depths[f.JSONName], len(f.Index))
+	}
+
+	var dominant []*FieldInfo
+	for _, f := range fields {
+		shallower, same := 0, 0
+		for _, depth := range depths[f.JSONName] {
+			if depth < len(f.Index) {
+				shallower++
+			} else if depth == len(f.Index) {
+				same++
+			}
+		}
+		if shallower == 0 && same == 1 {
+			dominant = append(dominant, f)
+		}
+	}
+	return dominant
+}
+
+// jsonFieldName returns the JSON name of a field and whether json ignores it
+func jsonFieldName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return sf.Name, true
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, false
+	}
+	return sf.Name, false
+}
+
+// parseTagOptions parses "column=id,pk,readonly" into a map
+func parseTagOptions(tag string) map[string]string {
+	options := make(map[string]string)
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		options[key] = value
+	}
+	return options
+}
+
+// SelectList returns the select parameter for the model, including its
+// relationships as embedded resources
+func (m *ModelInfo) SelectList() string {
+	return m.selectList(map[reflect.Type]bool{})
+}
+
+func (m *ModelInfo) selectList(visiting map[reflect.Type]bool) string {
+	visiting[m.Type] = true
+	defer delete(visiting, m.Type)
+
+	var items []string
+	for _, f := range m.Fields {
+		if f.JSONName != f.Column {
+			// Alias the column so the response decodes into the json name
+			items = append(items, fmt.Sprintf("%s:%s", f.JSONName, f.Column))
+		} else {
+			items = append(items, f.Column)
+		}
+	}
+
+	for _, rel := range m.Relations {
+		embed := rel.Table
+		if rel.ForeignKey != "" {
+			embed = fmt.Sprintf("%s!%s", embed, rel.ForeignKey)
+		}
+		if rel.JSONName != rel.Table {
+			embed = fmt.Sprintf("%s:%s", rel.JSONName, embed)
+		}
+
+		columns := "*"
+		if related, err := ModelOf(rel.Type); err == nil && rel.Type.Kind() == reflect.Struct {
+			if visiting[rel.Type] {
+				// Break cycles by selecting the related columns only
+				columns = related.columnList()
+			} else {
+				columns = related.selectList(visiting)
+			}
+		}
+
+		items = append(items, fmt.Sprintf("%s(%s)", embed, columns))
+	}
+
+	if len(items) == 0 {
+		return "*"
+	}
+	return strings.Join(items, ",")
+}
+
+// columnList returns the select parameter without relationships
+func (m *ModelInfo) columnList() string {
+	related := *m
+	related.Relations = nil
+	return related.selectList(map[reflect.Type]bool{})
+}
+
+// Values returns the columns to write for a model value
+// Read-only and relationship fields are never included; omitempty fields
+// are skipped when zero, and primary key and default fields are skipped on
+// insert when zero
+func (m *ModelInfo) Values(model interface{}, insert bool) map[string]interface{} {
+	return m.values(model, func(f *FieldInfo, fv reflect.Value) bool {
+		return fv.IsZero() && (f.OmitEmpty || (insert && (f.PK || f.Default)))
+	})
+}
+
+// writableValues returns every column that can be written for a model
+// value, including zero omitempty fields
+func (m *ModelInfo) writableValues(model interface{}) map[string]interface{} {
+	return m.values(model, func(*FieldInfo, reflect.Value) bool { return false })
+}
+
+// values returns the writable columns of a model value, except those skip
+// reports
+func (m *ModelInfo) values(model interface{}, skip func(*FieldInfo, reflect.Value) bool) map[string]interface{} {
+	v := reflect.Indirect(reflect.ValueOf(model))
+
+	values := make(map[string]interface{}, len(m.Fields))
+	for _, f := range m.Fields {
+		if f.ReadOnly {
+			continue
+		}
+
+		fv, err := v.FieldByIndexErr(f.Index)
+		if err != nil {
+			// The field is promoted from a nil embedded pointer
+			continue
+		}
+		if skip(f, fv) {
+			continue
+		}
+
+		values[f.Column] = fv.Interface()
+	}
+
+	return values
+}
+
+// PrimaryKeyValue returns the primary key of a model value
+func (m *ModelInfo) PrimaryKeyValue(model interface{}) (interface{}, error) {
+	if m.PrimaryKey == nil {
+		return nil, fmt.Errorf("model %s has no primary key", m.Type)
+	}
+	v := reflect.Indirect(reflect.ValueOf(model))
+	fv, err := v.FieldByIndexErr(m.PrimaryKey.Index)
+	if err != nil {
+		// The key is promoted from a nil embedded pointer
+		return reflect.Zero(m.Type.FieldByIndex(m.PrimaryKey.Index).Type).Interface(), nil
+	}
+	return fv.Interface(), nil
+}
+
+// hasTags reports whether the model declares any supabase or db tag, in
+// which case writes are encoded from the mapping instead of plain JSON
+func (m *ModelInfo) hasTags() bool {
+	return m.tagged
+}
+
+// encodeModelBody converts tagged models, or slices of them, into column
+// maps for insert and update. Other values are returned unchanged
+func encodeModelBody(data interface{}, insert bool) interface{} {
+	if data == nil {
+		return nil
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(data))
+	if !v.IsValid() {
+		return data
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		info, err := ModelOf(v.Type())
+		if err != nil || !info.hasTags() {
+			return data
+		}
+		return info.Values(v.Interface(), insert)
+	case reflect.Slice, reflect.Array:
+		info, err := ModelOf(v.Type())
+		if err != nil || !info.hasTags() {
+			return data
+		}
+		rows := make([]map[string]interface{}, v.Len())
+		for i := range rows {
+			rows[i] = info.Values(v.Index(i).Interface(), insert)
+		}
+		return rows
+	}
+
+	return data
+}
+
+// Model returns a query builder for the model's table, selecting the
+// model's columns and relationships. Delete hooks are run on model
+func (c *Client) Model(model interface{}) *QueryBuilder {
+	info, err := ModelOf(model)
+	if err != nil {
+		q := c.Table("")
+		q.err = err
+		return q
+	}
+
+	q := c.Table(info.Table)
+	q.model = info
+	q.instance = model
+	if info.SoftDelete != nil {
+		q.softDelete = info.SoftDelete.Column
+	}
+	if info.Table == "" {
+		q.err = fmt.Errorf("model %s has no table: implement TableNamer or call RegisterModel", info.Type)
+	}
+
+	return q.Select(info.SelectList())
+}
+
+// Find loads the row with the given primary key into result
+// The primary key comes from the model set with Client.Model or from result
+func (q *QueryBuilder) Find(id interface{}, result interface{}) error {
+	info := q.model
+	if info == nil {
+		var err error
+		if info, err = ModelOf(result); err != nil {
+			return err
+		}
+		if q.tableName == "" {
+			if info.Table == "" {
+				return fmt.Errorf("model %s has no table: implement TableNamer or call RegisterModel", info.Type)
+			}
+			q.tableName = info.Table
+		}
+		if len(q.selectFields) == 0 {
+			q.Select(info.SelectList())
+		}
+		if q.softDelete == "" && info.SoftDelete != nil {
+			q.softDelete = info.SoftDelete.Column
+		}
+	}
+
+	if info.PrimaryKey == nil {
+		return fmt.Errorf("model %s has no primary key", info.Type)
+	}
+
+	return q.Where(info.PrimaryKey.Column, "eq", id).Single().Get(result)
+}
@@ -0,0 +1,203 @@
+package supabaseorm
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type modelAuthor struct {
+	ID        int           `json:"id" supabase:"pk,readonly"`
+	Name      string        `json:"name"`
+	Email     string        `json:"email" supabase:"column=email_address"`
+	Bio       string        `json:"bio" supabase:"omitempty"`
+	CreatedAt time.Time     `json:"created_at" supabase:"default"`
+	Secret    string        `json:"-"`
+	Posts     []modelPost   `json:"posts" supabase:"rel=posts,fk=posts_author_id_fkey"`
+	Ignored   string        `json:"ignored" supabase:"-"`
+	Favorite  *modelComment `json:"favorite" supabase:"rel=comments"`
+}
+
+func (modelAuthor) TableName() string { return "authors" }
+
+type modelPost struct {
+	ID       int            `json:"id" supabase:"pk"`
+	Title    string         `json:"title"`
+	Comments []modelComment `json:"comments" supabase:"rel=comments"`
+}
+
+type modelComment struct {
+	ID     int          `json:"id"`
+	Author *modelAuthor `json:"author" supabase:"rel=authors"`
+}
+
+func TestModelOf(t *testing.T) {
+	info, err := ModelOf(&[]modelAuthor{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if info.Table != "authors" {
+		t.Errorf("Expected table to be authors, got %s", info.Table)
+	}
+	if info.PrimaryKey == nil || info.PrimaryKey.Column != "id" {
+		t.Errorf("Expected primary key id, got %+v", info.PrimaryKey)
+	}
+	if len(info.Fields) != 5 {
+		t.Errorf("Expected 5 fields, got %d", len(info.Fields))
+	}
+	if len(info.Relations) != 2 {
+		t.Errorf("Expected 2 relations, got %d", len(info.Relations))
+	}
+
+	if _, err := ModelOf(42); err == nil {
+		t.Error("Expected an error for a non-struct model")
+	}
+}
+
+type modelBase struct {
+	ID        int       `json:"id" supabase:"pk,readonly"`
+	CreatedAt time.Time `json:"created_at" supabase:"default"`
+}
+
+type modelAudit struct {
+	UpdatedBy string `json:"updated_by"`
+}
+
+type modelTag struct {
+	modelBase
+	*modelAudit
+	Name  string `json:"name" db:"tag_name"`
+	Color string `json:"color"`
+	Notes string `db:"-"`
+}
+
+func TestModelEmbeddedAndDBTags(t *testing.T) {
+	info, err := ModelOf(modelTag{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if info.PrimaryKey == nil || info.PrimaryKey.Column != "id" || len(info.PrimaryKey.Index) != 2 {
+		t.Errorf("Expected the promoted primary key id, got %+v", info.PrimaryKey)
+	}
+	if list := info.SelectList(); list != "id,created_at,updated_by,name:tag_name,color" {
+		t.Errorf("Unexpected select list %s", list)
+	}
+
+	// Fields promoted from a nil pointer are skipped
+	values := info.Values(&modelTag{Name: "go", Color: "blue"}, true)
+	if len(values) != 2 || values["tag_name"] != "go" || values["color"] != "blue" {
+		t.Errorf("Unexpected values %v", values)
+	}
+	values = info.Values(&modelTag{modelAudit: &modelAudit{UpdatedBy: "jane"}}, false)
+	if values["updated_by"] != "jane" {
+		t.Errorf("Expected updated_by to be written, got %v", values)
+	}
+
+	pk, err := info.PrimaryKeyValue(&modelTag{modelBase: modelBase{ID: 7}})
+	if err != nil || pk != 7 {
+		t.Errorf("Expected primary key 7, got %v, %v", pk, err)
+	}
+
+	// A field of the outer struct hides a promoted one with the same name
+	type shadowed struct {
+		modelBase
+		ID string `json:"id" db:"code"`
+	}
+	info, err = ModelOf(shadowed{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.PrimaryKey != nil || len(info.Fields) != 2 || info.Fields[1].Column != "code" {
+		t.Errorf("Expected the outer id to hide the promoted key, got %+v", info.Fields)
+	}
+}
+
+func TestModelSelectList(t *testing.T) {
+	info, _ := ModelOf(modelAuthor{})
+
+	expected := "id,name,email:email_address,bio,created_at," +
+		"posts!posts_author_id_fkey(id,title,comments(id,author:authors(id,name,email:email_address,bio,created_at)))," +
+		"favorite:comments(id,author:authors(id,name,email:email_address,bio,created_at))"
+	if info.SelectList() != expected {
+		t.Errorf("Expected select list\n%s\ngot\n%s", expected, info.SelectList())
+	}
+}
+
+func TestModelValues(t *testing.T) {
+	info, _ := ModelOf(modelAuthor{})
+
+	author := modelAuthor{ID: 7, Name: "Ada", Email: "ada@example.com", Secret: "x"}
+
+	insert := info.Values(author, true)
+	if _, ok := insert["id"]; ok {
+		t.Error("Expected readonly id to be excluded")
+	}
+	if _, ok := insert["bio"]; ok {
+		t.Error("Expected empty omitempty bio to be excluded")
+	}
+	if _, ok := insert["created_at"]; ok {
+		t.Error("Expected zero default created_at to be excluded on insert")
+	}
+	if insert["email_address"] != "ada@example.com" {
+		t.Errorf("Expected email_address column, got %v", insert)
+	}
+
+	update := info.Values(author, false)
+	if _, ok := update["created_at"]; !ok {
+		t.Error("Expected default created_at to be included on update")
+	}
+}
+
+func TestModelQueryAndFind(t *testing.T) {
+	var query, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Encode()
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"id":7,"name":"Ada","email":"ada@example.com"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key")
+
+	var author modelAuthor
+	if err := client.Model(&author).Find(7, &author); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if author.Email != "ada@example.com" {
+		t.Errorf("Expected aliased email to decode, got %q", author.Email)
+	}
+	if !strings.Contains(query, "id=eq.7") || !strings.Contains(query, "select=id%2Cname") {
+		t.Errorf("Expected primary key filter and model select, got %s", query)
+	}
+
+	if err := client.Model(&author).Where("id", "eq", 7).Update(&author); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var sent map[string]interface{}
+	json.Unmarshal([]byte(body), &sent)
+	if _, ok := sent["id"]; ok {
+		t.Errorf("Expected readonly id to be excluded from update, got %s", body)
+	}
+	if _, ok := sent["posts"]; ok {
+		t.Errorf("Expected relations to be excluded from update, got %s", body)
+	}
+}
+
+func TestModelWithoutTable(t *testing.T) {
+	client := New("https://example.com", "test-api-key")
+
+	var comments []modelComment
+	if err := client.Model(&comments).Get(&comments); err == nil {
+		t.Error("Expected an error for a model without a table")
+	}
+}
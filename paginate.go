@@ -0,0 +1,298 @@
+package supabaseorm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultPageSize is the number of rows per page used by Paginate
+const DefaultPageSize = 100
+
+// PaginateOption configures a Paginator
+type PaginateOption func(*paginateConfig)
+
+type paginateConfig struct {
+	pageSize   int
+	keyset     []string
+	descending bool
+}
+
+// PageSize sets the number of rows per page
+func PageSize(size int) PaginateOption {
+	return func(c *paginateConfig) {
+		c.pageSize = size
+	}
+}
+
+// KeysetOn paginates by the given columns instead of by offset
+// The columns must identify a row uniquely, e.g. ("created_at", "id"), and
+// replace any order set on the query
+func KeysetOn(columns ...string) PaginateOption {
+	return func(c *paginateConfig) {
+		c.keyset = columns
+	}
+}
+
+// Descending walks a keyset in descending order
+func Descending() PaginateOption {
+	return func(c *paginateConfig) {
+		c.descending = true
+	}
+}
+
+// Paginator iterates over the pages of a query
+//
+//	pages := client.Table("events").Paginate(ctx, PageSize(500), KeysetOn("created_at", "id"))
+//	for pages.Next() {
+//		var events []Event
+//		if err := pages.Scan(&events); err != nil { ... }
+//	}
+//	if err := pages.Err(); err != nil { ... }
+type Paginator struct {
+	query  *QueryBuilder
+	ctx    context.Context
+	config paginateConfig
+
+	rows  []json.RawMessage
+	page  int
+	total int
+	last  []string
+	done  bool
+	err   error
+}
+
+// Paginate returns a Paginator over the query's results
+// Without KeysetOn, pages are fetched by offset and Total reports the
+// number of matching rows
+func (q *QueryBuilder) Paginate(ctx context.Context, opts ...PaginateOption) *Paginator {
+	config := paginateConfig{pageSize: DefaultPageSize}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.pageSize <= 0 {
+		config.pageSize = DefaultPageSize
+	}
+
+	return &Paginator{
+		query:  q,
+		ctx:    ctx,
+		config: config,
+		page:   -1,
+		total:  -1,
+	}
+}
+
+// Next fetches the next page and reports whether it has any rows
+func (p *Paginator) Next() bool {
+	if p.done || p.err != nil {
+		return false
+	}
+
+	if len(p.config.keyset) > 0 {
+		p.err = p.fetchKeyset()
+	} else {
+		p.err = p.fetchOffset(p.page + 1)
+	}
+
+	if p.err != nil || len(p.rows) == 0 {
+		p.done = true
+		return false
+	}
+
+	if len(p.rows) < p.config.pageSize {
+		// Short page: this is the last one, but it still has rows to scan
+		p.done = true
+	}
+
+	return true
+}
+
+// FetchPage fetches the page with the given zero-based index
+// It is only available for offset pagination
+func (p *Paginator) FetchPage(page int) error {
+	if len(p.config.keyset) > 0 {
+		return fmt.Errorf("FetchPage is not supported with keyset pagination")
+	}
+
+	p.err = p.fetchOffset(page)
+	p.done = p.err != nil || len(p.rows) < p.config.pageSize
+	return p.err
+}
+
+// Scan decodes the rows of the current page into dest, a pointer to a
+// slice, and runs their AfterFind hooks
+func (p *Paginator) Scan(dest interface{}) error {
+	data, err := json.Marshal(p.rows)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return err
+	}
+	return runHook(p.ctx, dest, AfterFinder.AfterFind)
+}
+
+// Err returns the error that stopped the iteration, if any
+func (p *Paginator) Err() error {
+	return p.err
+}
+
+// Page returns the zero-based index of the current page
+func (p *Paginator) Page() int {
+	return p.page
+}
+
+// Total returns the number of matching rows, or -1 if it is not known
+// It is only known for offset pagination
+func (p *Paginator) Total() int {
+	return p.total
+}
+
+// PageCount returns the number of pages, or -1 if it is not known
+func (p *Paginator) PageCount() int {
+	if p.total < 0 {
+		return -1
+	}
+	return (p.total + p.config.pageSize - 1) / p.config.pageSize
+}
+
+// fetchOffset fetches a page by offset, requesting the exact count
+func (p *Paginator) fetchOffset(page int) error {
+	q := p.query.clone()
+	q.method = http.MethodGet
+	q.limitValue = p.config.pageSize
+	q.offsetValue = page * p.config.pageSize
+	q.Header("Prefer", q.preferHeader("count=exact"))
+
+	resp, err := q.send(q.newRequest().SetContext(p.ctx), nil)
+	if err != nil {
+		return err
+	}
+
+	if contentRange := resp.Header().Get("Content-Range"); contentRange != "" && !strings.HasSuffix(contentRange, "/*") {
+		_, _, p.total = ParseContentRange(contentRange)
+	}
+
+	p.page = page
+	p.rows = nil
+	return json.Unmarshal(resp.Body(), &p.rows)
+}
+
+// fetchKeyset fetches the page after the last row seen
+func (p *Paginator) fetchKeyset() error {
+	q := p.query.clone()
+	q.method = http.MethodGet
+	q.limitValue = p.config.pageSize
+	q.offsetValue = 0
+
+	direction, operator := "asc", "gt"
+	if p.config.descending {
+		direction, operator = "desc", "lt"
+	}
+
+	q.orderFields = nil
+	for _, column := range p.config.keyset {
+		q.Order(column, direction)
+	}
+
+	if p.last != nil {
+		q.WhereRaw(keysetCondition(p.config.keyset, p.last, operator))
+	}
+
+	resp, err := q.send(q.newRequest().SetContext(p.ctx), nil)
+	if err != nil {
+		return err
+	}
+
+	p.rows = nil
+	if err := json.Unmarshal(resp.Body(), &p.rows); err != nil {
+		return err
+	}
+
+	p.page++
+
+	if len(p.rows) == 0 {
+		return nil
+	}
+
+	last, err := keysetValues(p.rows[len(p.rows)-1], p.config.keyset)
+	if err != nil {
+		return err
+	}
+	p.last = last
+
+	return nil
+}
+
+// keysetCondition builds the row comparison (a,b) > (x,y) as a logical tree:
+// or(a.gt.x,and(a.eq.x,b.gt.y))
+func keysetCondition(columns, values []string, operator string) string {
+	var terms []string
+	for i := range columns {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s.eq.%s", columns[j], values[j]))
+		}
+		parts = append(parts, fmt.Sprintf("%s.%s.%s", columns[i], operator, values[i]))
+
+		if len(parts) == 1 {
+			terms = append(terms, parts[0])
+		} else {
+			terms = append(terms, fmt.Sprintf("and(%s)", strings.Join(parts, ",")))
+		}
+	}
+
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	return fmt.Sprintf("or(%s)", strings.Join(terms, ","))
+}
+
+// keysetValues extracts the keyset columns of a row, formatted for a logical tree
+func keysetValues(row json.RawMessage, columns []string) ([]string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(row, &fields); err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(columns))
+	for i, column := range columns {
+		raw, ok := fields[column]
+		if !ok {
+			return nil, fmt.Errorf("keyset column %q is not selected", column)
+		}
+
+		switch {
+		case string(raw) == "null":
+			return nil, fmt.Errorf("keyset column %q is null", column)
+		case len(raw) > 0 && raw[0] == '"':
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, err
+			}
+			values[i] = quoteTreeValue(s, true)
+		default:
+			// Numbers and booleans are used verbatim to keep their precision
+			values[i] = quoteTreeValue(string(raw), true)
+		}
+	}
+
+	return values, nil
+}
+
+// clone returns a copy of the builder that can be modified independently
+func (q *QueryBuilder) clone() *QueryBuilder {
+	c := *q
+	c.selectFields = append([]string(nil), q.selectFields...)
+	c.filters = append([]filter(nil), q.filters...)
+	c.orderFields = append([]order(nil), q.orderFields...)
+	c.joins = append([]join(nil), q.joins...)
+	c.headers = make(map[string]string, len(q.headers))
+	for k, v := range q.headers {
+		c.headers[k] = v
+	}
+	return &c
+}
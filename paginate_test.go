@@ -0,0 +1,123 @@
+package supabaseorm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type event struct {
+	ID        int    `json:"id"`
+	CreatedAt string `json:"created_at"`
+}
+
+var paginateEvents = []event{
+	{1, "2024-01-01T00:00:00Z"},
+	{2, "2024-01-02T00:00:00Z"},
+	{3, "2024-01-02T00:00:00Z"},
+	{4, "2024-01-03T00:00:00Z"},
+	{5, "2024-01-04T00:00:00Z"},
+}
+
+func TestPaginateKeyset(t *testing.T) {
+	var conditions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("order") != "created_at.asc,id.asc" {
+			t.Errorf("Expected keyset order, got %s", query.Get("order"))
+		}
+		conditions = append(conditions, query.Get("and"))
+
+		// Serve the page that follows the number of pages already served
+		start := (len(conditions) - 1) * 2
+		end := start + 2
+		if start > len(paginateEvents) {
+			start = len(paginateEvents)
+		}
+		if end > len(paginateEvents) {
+			end = len(paginateEvents)
+		}
+		json.NewEncoder(w).Encode(paginateEvents[start:end])
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key")
+
+	pages := client.Table("events").Paginate(context.Background(), PageSize(2), KeysetOn("created_at", "id"))
+
+	var ids []int
+	for pages.Next() {
+		var events []event
+		if err := pages.Scan(&events); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, e := range events {
+			ids = append(ids, e.ID)
+		}
+	}
+	if err := pages.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if fmt.Sprint(ids) != "[1 2 3 4 5]" {
+		t.Errorf("Expected all events, got %v", ids)
+	}
+
+	if len(conditions) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(conditions))
+	}
+	if conditions[0] != "" {
+		t.Errorf("Expected no keyset filter on the first page, got %s", conditions[0])
+	}
+	expected := `(or(created_at.gt."2024-01-02T00:00:00Z",and(created_at.eq."2024-01-02T00:00:00Z",id.gt.2)))`
+	if conditions[1] != expected {
+		t.Errorf("Expected keyset filter %s, got %s", expected, conditions[1])
+	}
+}
+
+func TestPaginateOffset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Prefer") != "count=exact" {
+			t.Errorf("Expected exact count, got %s", r.Header.Get("Prefer"))
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		end := offset + limit
+		if end > len(paginateEvents) {
+			end = len(paginateEvents)
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/%d", offset, end-1, len(paginateEvents)))
+		json.NewEncoder(w).Encode(paginateEvents[offset:end])
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key")
+
+	pages := client.Table("events").Paginate(context.Background(), PageSize(2))
+
+	if err := pages.FetchPage(2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var events []event
+	if err := pages.Scan(&events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(events) != 1 || events[0].ID != 5 {
+		t.Errorf("Expected the last event on page 2, got %v", events)
+	}
+	if pages.Total() != 5 || pages.PageCount() != 3 || pages.Page() != 2 {
+		t.Errorf("Expected total 5 in 3 pages, got %d in %d (page %d)", pages.Total(), pages.PageCount(), pages.Page())
+	}
+}
+
+func TestKeysetConditionSingleColumn(t *testing.T) {
+	if c := keysetCondition([]string{"id"}, []string{"10"}, "lt"); c != "id.lt.10" {
+		t.Errorf("Expected id.lt.10, got %s", c)
+	}
+}
@@ -1,8 +1,12 @@
 package supabaseorm
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -13,7 +17,9 @@ import (
 // QueryBuilder builds and executes queries against the Supabase API
 type QueryBuilder struct {
 	client       *Client
+	ctx          context.Context
 	tableName    string
+	schema       string
 	method       string
 	selectFields []string
 	filters      []filter
@@ -24,6 +30,20 @@ type QueryBuilder struct {
 	headers      map[string]string
 	joins        []join
 	rawQuery     string
+	rpc          string
+	rpcArgs      map[string]interface{}
+	accept       string
+	maybeSingle  bool
+	model        *ModelInfo
+	instance     interface{}
+	softDelete   string
+	trashed      trashedMode
+	scopes       []scope
+	fullTable    bool
+	maxAffected  int
+	idempotent   bool
+	cache        cachePolicy
+	err          error
 }
 
 type filter struct {
@@ -51,6 +71,12 @@ type join struct {
 	foreignColumn string
 }
 
+// WithContext sets the context used for the query's requests
+func (q *QueryBuilder) WithContext(ctx context.Context) *QueryBuilder {
+	q.ctx = ctx
+	return q
+}
+
 // Select specifies the columns to return
 func (q *QueryBuilder) Select(columns ...string) *QueryBuilder {
 	q.selectFields = columns
@@ -117,6 +143,12 @@ func (q *QueryBuilder) Range(start, end int) *QueryBuilder {
 	return q
 }
 
+// Schema sets the schema for this query, overriding the client's schema
+func (q *QueryBuilder) Schema(schema string) *QueryBuilder {
+	q.schema = schema
+	return q
+}
+
 // Header adds a custom header to the request
 func (q *QueryBuilder) Header(key, value string) *QueryBuilder {
 	if q.headers == nil {
@@ -162,6 +194,40 @@ func (q *QueryBuilder) Raw(query string) *QueryBuilder {
 	return q
 }
 
+// RPC returns a query calling the database function fn with GET, which
+// PostgREST allows for stable and immutable functions. args are sent as
+// query parameters, so they cannot be named like the parameters PostgREST
+// reserves (select, order, ...); functions returning rows can be filtered,
+// ordered and paginated like tables. Scopes and soft delete do not apply
+func (c *Client) RPC(fn string, args map[string]interface{}) *QueryBuilder {
+	q := &QueryBuilder{
+		client:  c,
+		schema:  c.schema,
+		method:  http.MethodGet,
+		rpc:     fn,
+		rpcArgs: args,
+	}
+	for name := range args {
+		if reservedParams[name] {
+			q.err = fmt.Errorf("argument %q of function %s is a reserved query parameter", name, fn)
+		}
+	}
+	return q
+}
+
+// reservedParams are the query parameters PostgREST does not read as
+// function arguments or filters
+var reservedParams = map[string]bool{
+	"select":      true,
+	"order":       true,
+	"limit":       true,
+	"offset":      true,
+	"columns":     true,
+	"on_conflict": true,
+	"and":         true,
+	"or":          true,
+}
+
 // Get executes the query and returns the results
 func (q *QueryBuilder) Get(result interface{}) error {
 	return q.execute(result)
@@ -186,168 +252,381 @@ func (q *QueryBuilder) Update(data interface{}) error {
 }
 
 // Delete deletes records
+// Tables with soft delete have their rows marked as deleted instead
 func (q *QueryBuilder) Delete() error {
+	if q.softDelete != "" {
+		return q.softDeleteRows()
+	}
+
 	q.method = http.MethodDelete
 	return q.execute(nil)
 }
 
 // Count returns the count of records
 func (q *QueryBuilder) Count() (int, error) {
-	q.Header("Prefer", "count=exact")
+	q.method = http.MethodHead
+
+	// The preference is set on the request only, so the builder can be counted again
+	req := q.newRequest()
+	req.SetHeader("Prefer", q.preferHeader("count=exact"))
 
-	var result json.RawMessage
-	err := q.execute(&result)
+	resp, err := q.send(req, nil)
 	if err != nil {
 		return 0, err
 	}
 
-	// Extract count from headers
-	// This is a placeholder - in a real implementation, you'd extract the count from the response headers
-	return 0, nil
+	_, _, total := ParseContentRange(resp.Header().Get("Content-Range"))
+	return total, nil
 }
 
 // execute builds and executes the request
 func (q *QueryBuilder) execute(data interface{}) error {
-	var endpoint string
+	// body is what gets sent; data is also the destination for the response
+	body := data
 
-	// If it's a raw query, use the RPC endpoint
+	// If it's a raw query, call the RPC endpoint with the SQL query as the body
+	// This assumes you have a function in your database that can execute the raw query
 	if q.rawQuery != "" {
-		// For raw SQL, we'll use the RPC endpoint
-		// This assumes you have a function in your database that can execute the raw query
-		endpoint = fmt.Sprintf("%s/rest/v1/rpc/execute_sql", q.client.GetBaseURL())
-
 		// Set the method to POST for RPC calls
 		q.method = http.MethodPost
 
-		// Create the request body with the SQL query
 		type sqlRequest struct {
 			Query string `json:"query"`
 		}
 
-		data = sqlRequest{
+		body = sqlRequest{
 			Query: q.rawQuery,
 		}
 	} else {
-		// For normal queries, use the table endpoint
-		endpoint = fmt.Sprintf("%s/rest/v1/%s", q.client.GetBaseURL(), q.tableName)
+		if err := q.beforeHooks(data); err != nil {
+			return err
+		}
+
+		if q.method == http.MethodPost || q.method == http.MethodPatch {
+			// Tagged models are written through their column mapping
+			body = encodeModelBody(data, q.method == http.MethodPost)
+		}
+
+		// Inserted rows get the columns pinned by the scopes
+		if q.method == http.MethodPost {
+			var err error
+			if body, err = q.scopeBody(body); err != nil {
+				return err
+			}
+		}
+	}
+
+	resp, err := q.send(q.newRequest(), body)
+	// MaybeSingle leaves data untouched when there is no row
+	if q.maybeSingle && errors.Is(err, ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := q.decodeResponse(resp.Body(), data); err != nil {
+		return err
+	}
+
+	if q.rawQuery != "" {
+		return nil
+	}
+	return q.afterHooks(data)
+}
+
+// decodeResponse decodes the returned rows into data, if any
+func (q *QueryBuilder) decodeResponse(body []byte, data interface{}) error {
+	if data == nil {
+		return nil
+	}
+
+	// For methods that return data, unmarshal the response
+	if q.method == http.MethodGet {
+		return json.Unmarshal(body, data)
+	}
+
+	// For insert operations and RPC calls, decode the returned rows
+	if q.method == http.MethodPost && len(body) > 0 {
+		return json.Unmarshal(body, data)
+	}
+
+	// Updates only return rows when asked for a representation
+	if q.method == http.MethodPatch && len(body) > 0 &&
+		strings.Contains(q.headers["Prefer"], "return=representation") {
+		return json.Unmarshal(body, data)
+	}
+
+	return nil
+}
+
+// beforeHooks runs the Before hooks of the models written by the query
+func (q *QueryBuilder) beforeHooks(data interface{}) error {
+	ctx := q.context()
+	switch q.method {
+	case http.MethodPost:
+		return runHook(ctx, data, BeforeInserter.BeforeInsert)
+	case http.MethodPatch:
+		return runHook(ctx, data, BeforeUpdater.BeforeUpdate)
+	case http.MethodDelete:
+		return runHook(ctx, q.instance, BeforeDeleter.BeforeDelete)
+	}
+	return nil
+}
+
+// afterHooks runs the After hooks of the models written or read by the query
+func (q *QueryBuilder) afterHooks(data interface{}) error {
+	ctx := q.context()
+	switch q.method {
+	case http.MethodGet:
+		return runHook(ctx, data, AfterFinder.AfterFind)
+	case http.MethodPost:
+		return runHook(ctx, data, AfterInserter.AfterInsert)
+	case http.MethodPatch:
+		return runHook(ctx, data, AfterUpdater.AfterUpdate)
+	case http.MethodDelete:
+		return runHook(ctx, q.instance, AfterDeleter.AfterDelete)
+	}
+	return nil
+}
+
+// endpoint returns the URL the query is sent to
+func (q *QueryBuilder) endpoint() string {
+	if q.rawQuery != "" {
+		return fmt.Sprintf("%s/rest/v1/rpc/execute_sql", q.client.GetBaseURL())
+	}
+	if q.rpc != "" {
+		return fmt.Sprintf("%s/rest/v1/rpc/%s", q.client.GetBaseURL(), q.rpc)
 	}
+	return fmt.Sprintf("%s/rest/v1/%s", q.client.GetBaseURL(), q.tableName)
+}
 
-	req := q.client.RawRequest()
+// newRequest creates a request with the context, schema, custom headers and
+// query parameters of the builder applied
+func (q *QueryBuilder) newRequest() *resty.Request {
+	req := q.client.httpClient.R().SetContext(q.context())
+
+	// Select the schema: Accept-Profile for reads, Content-Profile for writes and RPC
+	if q.schema != "" {
+		if q.method == http.MethodGet || q.method == http.MethodHead {
+			req.SetHeader("Accept-Profile", q.schema)
+		} else {
+			req.SetHeader("Content-Profile", q.schema)
+		}
+	}
+
+	// Request an alternative response format
+	if q.accept != "" {
+		req.SetHeader("Accept", q.accept)
+	}
 
 	// Add custom headers
 	for k, v := range q.headers {
 		req.SetHeader(k, v)
 	}
 
-	// If it's not a raw query, build the query parameters
-	if q.rawQuery == "" {
-		// Build query parameters
-		queryParams := url.Values{}
+	if prefs := q.maxAffectedPreferences(); prefs != nil {
+		req.SetHeader("Prefer", q.preferHeader(prefs...))
+	}
+
+	// Raw queries carry everything in the body
+	if q.rawQuery != "" {
+		return req
+	}
+
+	// Add range header if specified
+	if q.rangeValue != nil {
+		req.SetHeader("Range", fmt.Sprintf("%d-%d", q.rangeValue.start, q.rangeValue.end))
+	}
+
+	req.SetQueryParamsFromValues(q.buildQueryParams())
+
+	return req
+}
 
-		// Add select fields
+// buildQueryParams builds the select, filter, order and pagination parameters
+func (q *QueryBuilder) buildQueryParams() url.Values {
+	queryParams := url.Values{}
+
+	// Add select fields
+	if len(q.selectFields) > 0 {
+		queryParams.Set("select", strings.Join(q.selectFields, ","))
+	}
+
+	// Add joins
+	if len(q.joins) > 0 {
+		// For each join, we need to modify the select parameter
+		// to include the joined table columns
+		var joinSelects []string
+
+		for _, j := range q.joins {
+			// Format: foreignTable(*)
+			joinSelects = append(joinSelects, fmt.Sprintf("%s(*)", j.foreignTable))
+		}
+
+		// If we already have select fields, append the joins
 		if len(q.selectFields) > 0 {
-			queryParams.Set("select", strings.Join(q.selectFields, ","))
+			queryParams.Set("select", fmt.Sprintf("%s,%s",
+				queryParams.Get("select"),
+				strings.Join(joinSelects, ",")))
+		} else {
+			// Otherwise, select all columns from the main table and the joined tables
+			queryParams.Set("select", fmt.Sprintf("*,%s", strings.Join(joinSelects, ",")))
 		}
+	}
 
-		// Add joins
-		if len(q.joins) > 0 {
-			// For each join, we need to modify the select parameter
-			// to include the joined table columns
-			var joinSelects []string
+	// Add the arguments of a function
+	for name, value := range q.rpcArgs {
+		queryParams.Set(name, rpcArgValue(value))
+	}
 
-			for _, j := range q.joins {
-				// Format: foreignTable(*)
-				joinSelects = append(joinSelects, fmt.Sprintf("%s(*)", j.foreignTable))
-			}
+	// Add filters
+	q.addFilterParams(queryParams)
 
-			// If we already have select fields, append the joins
-			if len(q.selectFields) > 0 {
-				queryParams.Set("select", fmt.Sprintf("%s,%s",
-					queryParams.Get("select"),
-					strings.Join(joinSelects, ",")))
-			} else {
-				// Otherwise, select all columns from the main table and the joined tables
-				queryParams.Set("select", fmt.Sprintf("*,%s", strings.Join(joinSelects, ",")))
-			}
+	// Add order
+	if len(q.orderFields) > 0 {
+		var orders []string
+		for _, o := range q.orderFields {
+			orders = append(orders, fmt.Sprintf("%s.%s", o.column, o.direction))
 		}
+		queryParams.Set("order", strings.Join(orders, ","))
+	}
 
-		// Add filters
-		for _, f := range q.filters {
-			if f.isComplex {
-				// Handle raw conditions
-				queryParams.Add("and", f.column)
-			} else {
-				// Handle standard conditions
-				var condition string
-				if f.isOr {
-					condition = fmt.Sprintf("or(%s.%s.%v)", f.column, f.operator, f.value)
-				} else {
-					condition = fmt.Sprintf("%s.%s.%v", f.column, f.operator, f.value)
-				}
-				queryParams.Add("and", condition)
-			}
-		}
+	// Add limit and offset
+	if q.limitValue > 0 {
+		queryParams.Set("limit", fmt.Sprintf("%d", q.limitValue))
+	}
+
+	if q.offsetValue > 0 {
+		queryParams.Set("offset", fmt.Sprintf("%d", q.offsetValue))
+	}
 
-		// Add order
-		if len(q.orderFields) > 0 {
-			var orders []string
-			for _, o := range q.orderFields {
-				orders = append(orders, fmt.Sprintf("%s.%s", o.column, o.direction))
+	return queryParams
+}
+
+// addFilterParams encodes the filters as PostgREST query parameters
+// Each OrWhere is combined with the condition before it into an or=(...)
+// group; raw conditions are sent as and=(...). The soft delete filter and
+// the filters of each scope are grouped separately
+func (q *QueryBuilder) addFilterParams(queryParams url.Values) {
+	groups := groupFilters(q.filters)
+	if f, ok := q.softDeleteFilter(); ok {
+		groups = append(groups, []filter{f})
+	}
+	for _, scoped := range q.scopeFilters() {
+		groups = append(groups, groupFilters(scoped)...)
+	}
+
+	for _, group := range groups {
+		if len(group) > 1 {
+			var conditions []string
+			for _, f := range group {
+				conditions = append(conditions, f.condition())
 			}
-			queryParams.Set("order", strings.Join(orders, ","))
+			queryParams.Add("or", fmt.Sprintf("(%s)", strings.Join(conditions, ",")))
+			continue
 		}
 
-		// Add limit and offset
-		if q.limitValue > 0 {
-			queryParams.Set("limit", fmt.Sprintf("%d", q.limitValue))
+		f := group[0]
+		if f.isComplex {
+			queryParams.Add("and", fmt.Sprintf("(%s)", f.column))
+			continue
 		}
+		queryParams.Add(f.column, fmt.Sprintf("%s.%s", normalizeOperator(f.operator), formatParamValue(f.operator, f.value, false)))
+	}
+}
 
-		if q.offsetValue > 0 {
-			queryParams.Set("offset", fmt.Sprintf("%d", q.offsetValue))
+// groupFilters combines each OrWhere with the filters before it
+func groupFilters(filters []filter) [][]filter {
+	var groups [][]filter
+	for _, f := range filters {
+		if f.isOr && len(groups) > 0 {
+			groups[len(groups)-1] = append(groups[len(groups)-1], f)
+			continue
 		}
+		groups = append(groups, []filter{f})
+	}
+	return groups
+}
 
-		// Add range header if specified
-		if q.rangeValue != nil {
-			req.SetHeader("Range", fmt.Sprintf("%d-%d", q.rangeValue.start, q.rangeValue.end))
-		}
+// condition returns the filter in the logical tree syntax, e.g. age.gt.18
+func (f filter) condition() string {
+	if f.isComplex {
+		return f.column
+	}
+	return fmt.Sprintf("%s.%s.%s", f.column, normalizeOperator(f.operator), formatParamValue(f.operator, f.value, true))
+}
 
-		// Set query parameters
-		req.SetQueryParamsFromValues(queryParams)
+// send performs the request with the builder's method and checks the status
+func (q *QueryBuilder) send(req *resty.Request, body interface{}) (*resty.Response, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	if q.rpc != "" && q.method != http.MethodGet && q.method != http.MethodHead {
+		return nil, fmt.Errorf("function %s is called with GET and cannot be written to", q.rpc)
 	}
 
-	var resp *resty.Response
-	var err error
+	if q.rawQuery == "" {
+		if err := q.checkFullTable(); err != nil {
+			return nil, err
+		}
+		if err := q.checkScopes(); err != nil {
+			return nil, err
+		}
+		if err := q.checkScopedUpdate(body); err != nil {
+			return nil, err
+		}
+	}
+
+	// The retry transport reports its attempts through the context
+	attempts := 0
+	ctx := withAttemptCounter(q.withOperation(req.Context()), &attempts)
+	if q.idempotent {
+		ctx = context.WithValue(ctx, idempotentKey{}, true)
+	}
+	if q.cache != (cachePolicy{}) {
+		ctx = context.WithValue(ctx, cachePolicyKey{}, q.cache)
+	}
+	req.SetContext(ctx)
 
 	switch q.method {
-	case http.MethodGet:
-		resp, err = req.Get(endpoint)
-	case http.MethodPost:
-		resp, err = req.SetBody(data).Post(endpoint)
-	case http.MethodPatch:
-		resp, err = req.SetBody(data).Patch(endpoint)
-	case http.MethodDelete:
-		resp, err = req.Delete(endpoint)
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPatch, http.MethodDelete:
 	default:
-		return fmt.Errorf("unsupported HTTP method: %s", q.method)
+		return nil, fmt.Errorf("unsupported HTTP method: %s", q.method)
 	}
 
-	if err != nil {
-		return err
+	// Only writes carry a body
+	if q.method != http.MethodPost && q.method != http.MethodPatch {
+		body = nil
 	}
 
-	if resp.IsError() {
-		return fmt.Errorf("API error: %s", resp.String())
-	}
+	return q.roundTrip(req, body, &attempts)
+}
 
-	// For methods that return data, unmarshal the response
-	if q.method == http.MethodGet && data != nil {
-		return json.Unmarshal(resp.Body(), data)
+// apiError builds the error for a failed response
+// Unparsed (streamed) responses have their body read and closed
+func apiError(resp *resty.Response) *PostgrestError {
+	body := resp.Body()
+	if body == nil && resp.RawResponse != nil {
+		body, _ = io.ReadAll(resp.RawBody())
+		resp.RawBody().Close()
 	}
+	return newPostgrestError(resp.StatusCode(), bytes.TrimSpace(body))
+}
 
-	// For insert operations, update the ID of the inserted record
-	if q.method == http.MethodPost && data != nil {
-		return json.Unmarshal(resp.Body(), data)
+// context returns the builder's context, defaulting to context.Background
+func (q *QueryBuilder) context() context.Context {
+	if q.ctx != nil {
+		return q.ctx
 	}
+	return context.Background()
+}
 
-	return nil
+// preferHeader returns the Prefer header with the extra preferences appended
+func (q *QueryBuilder) preferHeader(extra ...string) string {
+	var prefs []string
+	if current := q.headers["Prefer"]; current != "" {
+		prefs = append(prefs, current)
+	}
+	return strings.Join(append(prefs, extra...), ",")
 }
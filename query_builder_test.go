@@ -1,6 +1,8 @@
 package supabaseorm
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -98,3 +100,119 @@ func TestMultipleJoins(t *testing.T) {
 		t.Errorf("Expected second foreign table to be 'comments', got '%s'", join2.foreignTable)
 	}
 }
+
+func TestSchemaProfileHeaders(t *testing.T) {
+	var accept, content string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept-Profile")
+		content = r.Header.Get("Content-Profile")
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key").Schema("billing")
+
+	var rows []map[string]interface{}
+	if err := client.Table("invoices").InnerJoin("customers", "customer_id", "id").Get(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if accept != "billing" || content != "" {
+		t.Errorf("Expected Accept-Profile billing on read, got %q / %q", accept, content)
+	}
+
+	if err := client.Table("invoices").Where("id", "eq", 1).Delete(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if content != "billing" || accept != "" {
+		t.Errorf("Expected Content-Profile billing on write, got %q / %q", accept, content)
+	}
+
+	if err := client.Table("").Raw("SELECT 1").Get(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if content != "billing" {
+		t.Errorf("Expected Content-Profile billing on RPC, got %q", content)
+	}
+
+	if _, err := client.RawRequest().Post(server.URL + "/rest/v1/rpc/close_month"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if accept != "billing" || content != "billing" {
+		t.Errorf("Expected both profiles billing on a raw request, got %q / %q", accept, content)
+	}
+}
+
+func TestFilterParams(t *testing.T) {
+	client := &Client{
+		baseURL: "https://example.com",
+		apiKey:  "test-api-key",
+	}
+
+	params := client.Table("users").
+		Where("age", ">", 18).
+		Where("name", "eq", "John").
+		OrWhere("name", "eq", "Doe, Jane").
+		Where("id", "in", []int{1, 2}).
+		WhereRaw("or(role.eq.admin,role.eq.owner)").
+		buildQueryParams()
+
+	expected := "age=gt.18&and=%28or%28role.eq.admin%2Crole.eq.owner%29%29&id=in.%281%2C2%29&or=%28name.eq.John%2Cname.eq.%22Doe%2C+Jane%22%29"
+	if params.Encode() != expected {
+		t.Errorf("Expected %s, got %s", expected, params.Encode())
+	}
+}
+
+func TestCount(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.Header.Get("Prefer"))
+		w.Header().Set("Content-Range", "0-1/7")
+	}))
+	defer server.Close()
+
+	query := New(server.URL, "test-api-key").Table("users").Where("active", "eq", true)
+	for i := 0; i < 2; i++ {
+		count, err := query.Count()
+		if err != nil || count != 7 {
+			t.Fatalf("Expected a count of 7, got %d, %v", count, err)
+		}
+	}
+
+	for _, request := range requests {
+		if request != "HEAD count=exact" {
+			t.Errorf("Expected HEAD with count=exact, got %q", request)
+		}
+	}
+}
+
+func TestRPC(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		w.Write([]byte(`[{"name":"Paris"}]`))
+	}))
+	defer server.Close()
+	client := New(server.URL, "test-api-key")
+
+	var rows []map[string]interface{}
+	args := map[string]interface{}{"tags": []string{"a", "b c"}}
+	if err := client.RPC("nearby", args).Where("name", "like", "P*").Get(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["name"] != "Paris" {
+		t.Errorf("Unexpected rows %v", rows)
+	}
+	if want := `GET /rest/v1/rpc/nearby?name=like.P%2A&tags=%7Ba%2C%22b+c%22%7D`; len(requests) != 1 || requests[0] != want {
+		t.Errorf("Expected %s, got %v", want, requests)
+	}
+
+	if err := client.RPC("nearby", map[string]interface{}{"limit": 5}).Get(&rows); err == nil {
+		t.Error("Expected a reserved argument name to fail")
+	}
+	if err := client.RPC("nearby", nil).Where("id", "eq", 1).Delete(); err == nil {
+		t.Error("Expected writing to a function to fail")
+	}
+	if len(requests) != 1 {
+		t.Errorf("Expected failed calls not to be sent, got %v", requests)
+	}
+}
@@ -0,0 +1,136 @@
+package supabaseorm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// Path prefixes of the Supabase APIs, for route limits and circuit breakers
+const (
+	RouteREST      = "/rest/v1/"
+	RouteAuth      = "/auth/v1/"
+	RouteFunctions = "/functions/v1/"
+	RouteStorage   = "/storage/v1/"
+	RouteRealtime  = "/realtime/v1/"
+)
+
+// WithRateLimit limits the client to rps requests per second, allowing
+// bursts of up to burst requests. The limit is shared by all requests of the
+// client, and waiting requests return when their context is done
+func WithRateLimit(rps float64, burst int) ClientOption {
+	return func(c *Client) {
+		c.limits().rate = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+}
+
+// WithRouteRateLimit adds a limit for requests to one API, e.g. RouteAuth,
+// on top of the client-wide limit
+func WithRouteRateLimit(route string, rps float64, burst int) ClientOption {
+	return func(c *Client) {
+		c.limits().routes = append(c.limits().routes, routeLimit{
+			route:   route,
+			limiter: rate.NewLimiter(rate.Limit(rps), burst),
+		})
+	}
+}
+
+// WithMaxConcurrentRequests caps the number of requests in flight
+// A streamed response counts until its body is closed
+func WithMaxConcurrentRequests(n int) ClientOption {
+	return func(c *Client) {
+		c.limits().slots = make(chan struct{}, n)
+	}
+}
+
+// requestLimits holds the rate and concurrency limits of a client
+type requestLimits struct {
+	rate   *rate.Limiter
+	routes []routeLimit
+	slots  chan struct{}
+}
+
+type routeLimit struct {
+	route   string
+	limiter *rate.Limiter
+}
+
+// limits returns the client's limits, creating them on first use
+func (c *Client) limits() *requestLimits {
+	if c.limiter == nil {
+		c.limiter = &requestLimits{}
+	}
+	return c.limiter
+}
+
+// limitTransport waits for the rate limits and a concurrency slot
+type limitTransport struct {
+	next   http.RoundTripper
+	limits *requestLimits
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for _, route := range t.limits.routes {
+		if strings.Contains(req.URL.Path, route.route) {
+			if err := route.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if t.limits.rate != nil {
+		if err := t.limits.rate.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.limits.slots == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	release, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// acquire takes a concurrency slot and returns the function releasing it
+func (t *limitTransport) acquire(ctx context.Context) (func(), error) {
+	select {
+	case t.limits.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-t.limits.slots })
+	}, nil
+}
+
+// releaseBody releases a concurrency slot when the body is closed
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
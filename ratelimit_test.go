@@ -0,0 +1,99 @@
+package supabaseorm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key", WithMaxConcurrentRequests(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var rows []map[string]interface{}
+			if err := client.Table("users").Get(&rows); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 requests in flight, got %d", maxInFlight)
+	}
+}
+
+func TestRateLimitWaitIsCancellable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key", WithRateLimit(0.1, 1))
+
+	var rows []map[string]interface{}
+	if err := client.Table("users").Get(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := client.Table("users").WithContext(ctx).Get(&rows); err == nil {
+		t.Error("Expected the rate limited request to fail with its context")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the wait to stop with the context, took %v", elapsed)
+	}
+}
+
+func TestRouteRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-api-key", WithRouteRateLimit(RouteAuth, 0.1, 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// REST requests are not limited by the auth route
+	for i := 0; i < 3; i++ {
+		var rows map[string]interface{}
+		if err := client.Table("users").WithContext(ctx).Get(&rows); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if _, err := client.Auth().GetUser(ctx, "token"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Auth().GetUser(ctx, "token"); err == nil {
+		t.Error("Expected the second auth request to be rate limited")
+	}
+}
@@ -0,0 +1,632 @@
+package supabaseorm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Phoenix protocol events used by Supabase Realtime
+const (
+	phxJoin      = "phx_join"
+	phxLeave     = "phx_leave"
+	phxReply     = "phx_reply"
+	phxClose     = "phx_close"
+	phxError     = "phx_error"
+	phxHeartbeat = "heartbeat"
+
+	realtimeAccessToken   = "access_token"
+	realtimeBroadcast     = "broadcast"
+	realtimePresence      = "presence"
+	realtimePresenceState = "presence_state"
+	realtimePresenceDiff  = "presence_diff"
+)
+
+// DefaultHeartbeatInterval is how often the realtime socket sends a heartbeat
+const DefaultHeartbeatInterval = 25 * time.Second
+
+// ErrRealtimeClosed is returned when the realtime socket is closed while a
+// message is being sent or a reply is awaited
+var ErrRealtimeClosed = errors.New("realtime connection closed")
+
+// phoenixMessage is a single frame of the Phoenix channel protocol
+type phoenixMessage struct {
+	Topic   string          `json:"topic"`
+	Event   string          `json:"event"`
+	Payload json.RawMessage `json:"payload"`
+	Ref     string          `json:"ref,omitempty"`
+	JoinRef string          `json:"join_ref,omitempty"`
+}
+
+// phoenixReply is the payload of a phx_reply frame
+type phoenixReply struct {
+	Status   string          `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+// Realtime manages the websocket connection to Supabase Realtime
+type Realtime struct {
+	client            *Client
+	heartbeatInterval time.Duration
+
+	mu       sync.Mutex
+	conn     *websocket.Conn
+	done     chan struct{}
+	ref      uint64
+	token    string
+	channels map[string]*Channel
+	pending  map[string]chan *phoenixMessage
+
+	writeMu sync.Mutex
+}
+
+// NewRealtime creates a new Realtime instance
+func NewRealtime(client *Client) *Realtime {
+	return &Realtime{
+		client:            client,
+		heartbeatInterval: DefaultHeartbeatInterval,
+		channels:          make(map[string]*Channel),
+		pending:           make(map[string]chan *phoenixMessage),
+	}
+}
+
+// SetHeartbeatInterval changes the interval between heartbeats
+// It must be called before the first channel is subscribed
+func (r *Realtime) SetHeartbeatInterval(interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.heartbeatInterval = interval
+}
+
+// SetAuth sets the JWT sent to authorize channels, e.g. the access token of
+// a signed-in user, so that private channels and presence are checked
+// against their RLS policies. Joined channels get the new token right away.
+// Without it, the client's Authorization header is sent
+func (r *Realtime) SetAuth(ctx context.Context, token string) error {
+	r.mu.Lock()
+	r.token = token
+	var joined []*Channel
+	for _, ch := range r.channels {
+		if ch.isJoined() {
+			joined = append(joined, ch)
+		}
+	}
+	r.mu.Unlock()
+
+	payload, err := json.Marshal(map[string]string{"access_token": token})
+	if err != nil {
+		return err
+	}
+	for _, ch := range joined {
+		if _, err := r.push(ctx, ch.message(realtimeAccessToken, payload), false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accessToken returns the JWT authorizing channels
+func (r *Realtime) accessToken() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.token != "" {
+		return r.token
+	}
+	return strings.TrimPrefix(r.client.httpClient.Header.Get("Authorization"), "Bearer ")
+}
+
+// Channel returns a channel for the given name
+// The channel is not joined until Subscribe is called
+func (r *Realtime) Channel(name string, config ChannelConfig) *Channel {
+	ch := &Channel{
+		realtime:   r,
+		name:       name,
+		topic:      "realtime:" + name,
+		config:     config,
+		broadcasts: make(map[string][]BroadcastHandler),
+		presence:   newPresenceTracker(),
+	}
+
+	r.mu.Lock()
+	r.channels[ch.topic] = ch
+	r.mu.Unlock()
+
+	return ch
+}
+
+// Connect opens the websocket connection if it is not already open
+func (r *Realtime) Connect(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn != nil {
+		return nil
+	}
+
+	endpoint, err := r.endpoint()
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("realtime connect: %w", err)
+	}
+
+	r.conn = conn
+	r.done = make(chan struct{})
+
+	go r.readLoop(conn, r.done)
+	go r.heartbeatLoop(r.heartbeatInterval, r.done)
+
+	return nil
+}
+
+// Close closes the websocket connection
+func (r *Realtime) Close() error {
+	// Connect opens a new connection once the old one is cleared, without
+	// waiting for its read loop to exit
+	r.mu.Lock()
+	conn := r.conn
+	r.conn = nil
+	for _, ch := range r.channels {
+		ch.setJoined(false)
+	}
+	r.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	r.writeMu.Lock()
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	r.writeMu.Unlock()
+
+	return conn.Close()
+}
+
+// Broadcast sends a broadcast message over the REST endpoint
+// This does not require a websocket connection
+func (r *Realtime) Broadcast(ctx context.Context, channel string, msg Broadcast, private bool) error {
+	type broadcastMessage struct {
+		Topic   string      `json:"topic"`
+		Event   string      `json:"event"`
+		Payload interface{} `json:"payload"`
+		Private bool        `json:"private,omitempty"`
+	}
+
+	body := map[string][]broadcastMessage{
+		"messages": {{
+			Topic:   channel,
+			Event:   msg.Event,
+			Payload: msg.Payload,
+			Private: private,
+		}},
+	}
+
+	endpoint := fmt.Sprintf("%s/realtime/v1/api/broadcast", r.client.baseURL)
+
+	resp, err := r.client.httpClient.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+r.accessToken()).
+		SetBody(body).
+		Post(endpoint)
+
+	if err != nil {
+		return err
+	}
+
+	if resp.IsError() {
+		return fmt.Errorf("realtime error: %s", resp.String())
+	}
+
+	return nil
+}
+
+// endpoint returns the websocket URL for the client's project
+func (r *Realtime) endpoint() (string, error) {
+	u, err := url.Parse(r.client.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/realtime/v1/websocket"
+	u.RawQuery = url.Values{
+		"apikey": {r.client.apiKey},
+		"vsn":    {"1.0.0"},
+	}.Encode()
+
+	return u.String(), nil
+}
+
+// nextRef returns a new message reference
+func (r *Realtime) nextRef() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ref++
+	return strconv.FormatUint(r.ref, 10)
+}
+
+// push writes a message to the socket and, if wantReply is set, waits for
+// the matching phx_reply
+func (r *Realtime) push(ctx context.Context, msg phoenixMessage, wantReply bool) (*phoenixReply, error) {
+	r.mu.Lock()
+	conn, done := r.conn, r.done
+	var replyCh chan *phoenixMessage
+	if wantReply {
+		replyCh = make(chan *phoenixMessage, 1)
+		r.pending[msg.Ref] = replyCh
+	}
+	r.mu.Unlock()
+
+	if conn == nil {
+		return nil, ErrRealtimeClosed
+	}
+
+	defer func() {
+		if wantReply {
+			r.mu.Lock()
+			delete(r.pending, msg.Ref)
+			r.mu.Unlock()
+		}
+	}()
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.writeMu.Lock()
+	err = conn.WriteMessage(websocket.TextMessage, data)
+	r.writeMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("realtime write: %w", err)
+	}
+
+	if !wantReply {
+		return nil, nil
+	}
+
+	select {
+	case reply := <-replyCh:
+		var payload phoenixReply
+		if err := json.Unmarshal(reply.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("failed to parse realtime reply: %w", err)
+		}
+		if payload.Status != "ok" {
+			return &payload, fmt.Errorf("realtime error: %s %s", payload.Status, string(payload.Response))
+		}
+		return &payload, nil
+	case <-done:
+		return nil, ErrRealtimeClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// readLoop dispatches incoming frames until the connection is closed
+func (r *Realtime) readLoop(conn *websocket.Conn, done chan struct{}) {
+	defer func() {
+		r.mu.Lock()
+		// Channels joined on a newer connection stay joined
+		if r.conn == conn {
+			r.conn = nil
+			for _, ch := range r.channels {
+				ch.setJoined(false)
+			}
+		}
+		r.mu.Unlock()
+		close(done)
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg phoenixMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		if msg.Event == phxReply {
+			r.mu.Lock()
+			replyCh, ok := r.pending[msg.Ref]
+			r.mu.Unlock()
+			if ok {
+				select {
+				case replyCh <- &msg:
+				default:
+				}
+			}
+			continue
+		}
+
+		r.mu.Lock()
+		ch, ok := r.channels[msg.Topic]
+		r.mu.Unlock()
+		if ok {
+			ch.handle(&msg)
+		}
+	}
+}
+
+// heartbeatLoop keeps the connection alive until it is closed
+func (r *Realtime) heartbeatLoop(interval time.Duration, done chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			_, _ = r.push(context.Background(), phoenixMessage{
+				Topic:   "phoenix",
+				Event:   phxHeartbeat,
+				Payload: json.RawMessage("{}"),
+				Ref:     r.nextRef(),
+			}, false)
+		}
+	}
+}
+
+// ChannelConfig configures a realtime channel
+type ChannelConfig struct {
+	Broadcast BroadcastConfig `json:"broadcast"`
+	Presence  PresenceConfig  `json:"presence"`
+	Private   bool            `json:"private"`
+}
+
+// BroadcastConfig configures broadcast behaviour for a channel
+type BroadcastConfig struct {
+	// Self delivers the client's own broadcasts back to it
+	Self bool `json:"self"`
+	// Ack makes Send wait for the server to acknowledge each broadcast
+	Ack bool `json:"ack"`
+}
+
+// PresenceConfig configures presence for a channel
+type PresenceConfig struct {
+	// Key identifies this client in the presence state
+	// The server generates one if it is empty
+	Key string `json:"key"`
+}
+
+// Broadcast is a message sent to or received from a channel
+type Broadcast struct {
+	Event   string      `json:"event"`
+	Payload interface{} `json:"payload"`
+}
+
+// BroadcastHandler handles an incoming broadcast payload
+type BroadcastHandler func(event string, payload json.RawMessage)
+
+// Channel is a realtime channel supporting broadcast and presence
+type Channel struct {
+	realtime *Realtime
+	name     string
+	topic    string
+	config   ChannelConfig
+
+	mu         sync.Mutex
+	joined     bool
+	joinRef    string
+	broadcasts map[string][]BroadcastHandler
+	presence   *presenceTracker
+}
+
+// Name returns the channel name
+func (ch *Channel) Name() string {
+	return ch.name
+}
+
+// Subscribe connects the socket if needed and joins the channel
+func (ch *Channel) Subscribe(ctx context.Context) error {
+	if err := ch.realtime.Connect(ctx); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"config":       ch.config,
+		"access_token": ch.realtime.accessToken(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// An unsubscribed channel is registered again
+	ch.realtime.mu.Lock()
+	ch.realtime.channels[ch.topic] = ch
+	ch.realtime.mu.Unlock()
+
+	ref := ch.realtime.nextRef()
+	ch.presence.reset()
+
+	if _, err := ch.realtime.push(ctx, phoenixMessage{
+		Topic:   ch.topic,
+		Event:   phxJoin,
+		Payload: payload,
+		Ref:     ref,
+		JoinRef: ref,
+	}, true); err != nil {
+		return err
+	}
+
+	ch.mu.Lock()
+	ch.joined = true
+	ch.joinRef = ref
+	ch.mu.Unlock()
+
+	return nil
+}
+
+// Unsubscribe leaves the channel, which stops receiving frames until it is
+// subscribed again
+func (ch *Channel) Unsubscribe(ctx context.Context) error {
+	ch.realtime.mu.Lock()
+	if ch.realtime.channels[ch.topic] == ch {
+		delete(ch.realtime.channels, ch.topic)
+	}
+	ch.realtime.mu.Unlock()
+
+	if !ch.isJoined() {
+		return nil
+	}
+
+	_, err := ch.realtime.push(ctx, ch.message(phxLeave, json.RawMessage("{}")), true)
+	ch.setJoined(false)
+
+	return err
+}
+
+// OnBroadcast registers a handler for broadcasts with the given event name
+// Use "*" to receive every event. Handlers run on the socket's read
+// goroutine and should not block
+func (ch *Channel) OnBroadcast(event string, handler BroadcastHandler) *Channel {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.broadcasts[event] = append(ch.broadcasts[event], handler)
+	return ch
+}
+
+// Send broadcasts a message to the channel
+// If the channel is not subscribed, the message is sent over HTTP instead
+func (ch *Channel) Send(ctx context.Context, msg Broadcast) error {
+	if !ch.isJoined() {
+		return ch.realtime.Broadcast(ctx, ch.name, msg, ch.config.Private)
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":    realtimeBroadcast,
+		"event":   msg.Event,
+		"payload": msg.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = ch.realtime.push(ctx, ch.message(realtimeBroadcast, payload), ch.config.Broadcast.Ack)
+	return err
+}
+
+// Track publishes this client's presence state on the channel
+func (ch *Channel) Track(ctx context.Context, state interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":    realtimePresence,
+		"event":   "track",
+		"payload": state,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = ch.realtime.push(ctx, ch.message(realtimePresence, payload), true)
+	return err
+}
+
+// Untrack removes this client's presence state from the channel
+func (ch *Channel) Untrack(ctx context.Context) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":  realtimePresence,
+		"event": "untrack",
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = ch.realtime.push(ctx, ch.message(realtimePresence, payload), true)
+	return err
+}
+
+// OnPresence registers a handler for presence sync, join or leave events
+// Handlers run on the socket's read goroutine and should not block
+func (ch *Channel) OnPresence(event PresenceEventType, handler PresenceHandler) *Channel {
+	ch.presence.on(event, handler)
+	return ch
+}
+
+// PresenceState returns a copy of the current presence state
+func (ch *Channel) PresenceState() PresenceState {
+	return ch.presence.snapshot()
+}
+
+// message builds a frame for this channel with a fresh reference
+func (ch *Channel) message(event string, payload json.RawMessage) phoenixMessage {
+	ch.mu.Lock()
+	joinRef := ch.joinRef
+	ch.mu.Unlock()
+
+	return phoenixMessage{
+		Topic:   ch.topic,
+		Event:   event,
+		Payload: payload,
+		Ref:     ch.realtime.nextRef(),
+		JoinRef: joinRef,
+	}
+}
+
+// handle processes a frame addressed to this channel
+func (ch *Channel) handle(msg *phoenixMessage) {
+	switch msg.Event {
+	case realtimeBroadcast:
+		var payload struct {
+			Event   string          `json:"event"`
+			Payload json.RawMessage `json:"payload"`
+		}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return
+		}
+
+		ch.mu.Lock()
+		handlers := append(append([]BroadcastHandler(nil), ch.broadcasts[payload.Event]...), ch.broadcasts["*"]...)
+		ch.mu.Unlock()
+
+		for _, handler := range handlers {
+			handler(payload.Event, payload.Payload)
+		}
+	case realtimePresenceState:
+		var state map[string]presenceEntry
+		if err := json.Unmarshal(msg.Payload, &state); err != nil {
+			return
+		}
+		ch.presence.syncState(state)
+	case realtimePresenceDiff:
+		var diff presenceDiff
+		if err := json.Unmarshal(msg.Payload, &diff); err != nil {
+			return
+		}
+		ch.presence.syncDiff(diff)
+	case phxClose, phxError:
+		ch.setJoined(false)
+	}
+}
+
+func (ch *Channel) isJoined() bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return ch.joined
+}
+
+func (ch *Channel) setJoined(joined bool) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.joined = joined
+}
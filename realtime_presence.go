@@ -0,0 +1,284 @@
+package supabaseorm
+
+import (
+	"sort"
+	"sync"
+)
+
+// PresenceEventType is the type of a presence event
+type PresenceEventType string
+
+const (
+	// PresenceSync fires after the presence state has changed
+	PresenceSync PresenceEventType = "sync"
+	// PresenceJoin fires when a key gains one or more presences
+	PresenceJoin PresenceEventType = "join"
+	// PresenceLeave fires when a key loses one or more presences
+	PresenceLeave PresenceEventType = "leave"
+)
+
+// Presence is a single tracked state, including the server-assigned phx_ref
+type Presence map[string]interface{}
+
+// Ref returns the server-assigned reference of the presence
+func (p Presence) Ref() string {
+	ref, _ := p["phx_ref"].(string)
+	return ref
+}
+
+// PresenceState maps presence keys to their tracked states
+type PresenceState map[string][]Presence
+
+// PresenceEvent describes a presence change
+// For sync events only Type and State are set
+type PresenceEvent struct {
+	Type PresenceEventType
+	Key  string
+	// Current holds the presences of Key before a join, or the presences
+	// that remain after a leave
+	Current []Presence
+	// Changed holds the presences that joined or left
+	Changed []Presence
+	// State is the full presence state after the change
+	State PresenceState
+}
+
+// PresenceHandler handles a presence event
+type PresenceHandler func(event PresenceEvent)
+
+// presenceEntry is the wire format of a key's presences
+type presenceEntry struct {
+	Metas []Presence `json:"metas"`
+}
+
+// presenceDiff is the payload of a presence_diff frame
+type presenceDiff struct {
+	Joins  map[string]presenceEntry `json:"joins"`
+	Leaves map[string]presenceEntry `json:"leaves"`
+}
+
+// presenceTracker keeps the client-side presence state of a channel
+type presenceTracker struct {
+	mu       sync.Mutex
+	state    PresenceState
+	synced   bool
+	pending  []presenceDiff
+	handlers map[PresenceEventType][]PresenceHandler
+}
+
+func newPresenceTracker() *presenceTracker {
+	return &presenceTracker{
+		state:    PresenceState{},
+		handlers: make(map[PresenceEventType][]PresenceHandler),
+	}
+}
+
+// on registers a handler
+func (p *presenceTracker) on(event PresenceEventType, handler PresenceHandler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[event] = append(p.handlers[event], handler)
+}
+
+// reset clears the state before a (re)join
+func (p *presenceTracker) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state = PresenceState{}
+	p.synced = false
+	p.pending = nil
+}
+
+// snapshot returns a copy of the current state
+func (p *presenceTracker) snapshot() PresenceState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state.clone()
+}
+
+// syncState replaces the state with a full server snapshot, emitting the
+// joins and leaves needed to get there, then applies any buffered diffs
+func (p *presenceTracker) syncState(entries map[string]presenceEntry) {
+	p.mu.Lock()
+
+	next := make(PresenceState, len(entries))
+	for key, entry := range entries {
+		next[key] = entry.Metas
+	}
+
+	events := applyPresenceDiff(p.state, diffPresenceState(p.state, next))
+	for _, diff := range p.pending {
+		events = append(events, applyPresenceDiff(p.state, diff)...)
+	}
+	p.pending = nil
+	p.synced = true
+
+	events = append(events, PresenceEvent{Type: PresenceSync, State: p.state.clone()})
+	p.mu.Unlock()
+
+	p.emit(events)
+}
+
+// syncDiff applies an incremental diff
+// Diffs that arrive before the first snapshot are buffered
+func (p *presenceTracker) syncDiff(diff presenceDiff) {
+	p.mu.Lock()
+
+	if !p.synced {
+		p.pending = append(p.pending, diff)
+		p.mu.Unlock()
+		return
+	}
+
+	events := applyPresenceDiff(p.state, diff)
+	events = append(events, PresenceEvent{Type: PresenceSync, State: p.state.clone()})
+	p.mu.Unlock()
+
+	p.emit(events)
+}
+
+// emit calls the registered handlers for each event
+func (p *presenceTracker) emit(events []PresenceEvent) {
+	p.mu.Lock()
+	handlers := make(map[PresenceEventType][]PresenceHandler, len(p.handlers))
+	for event, hs := range p.handlers {
+		handlers[event] = append([]PresenceHandler(nil), hs...)
+	}
+	p.mu.Unlock()
+
+	for _, event := range events {
+		for _, handler := range handlers[event.Type] {
+			handler(event)
+		}
+	}
+}
+
+// diffPresenceState computes the diff that turns current into next
+func diffPresenceState(current, next PresenceState) presenceDiff {
+	diff := presenceDiff{
+		Joins:  make(map[string]presenceEntry),
+		Leaves: make(map[string]presenceEntry),
+	}
+
+	for key, presences := range current {
+		if _, ok := next[key]; !ok {
+			diff.Leaves[key] = presenceEntry{Metas: presences}
+		}
+	}
+
+	for key, nextPresences := range next {
+		currentPresences, ok := current[key]
+		if !ok {
+			diff.Joins[key] = presenceEntry{Metas: nextPresences}
+			continue
+		}
+
+		currentRefs := presenceRefs(currentPresences)
+		nextRefs := presenceRefs(nextPresences)
+
+		var joined, left []Presence
+		for _, presence := range nextPresences {
+			if !currentRefs[presence.Ref()] {
+				joined = append(joined, presence)
+			}
+		}
+		for _, presence := range currentPresences {
+			if !nextRefs[presence.Ref()] {
+				left = append(left, presence)
+			}
+		}
+
+		if len(joined) > 0 {
+			diff.Joins[key] = presenceEntry{Metas: joined}
+		}
+		if len(left) > 0 {
+			diff.Leaves[key] = presenceEntry{Metas: left}
+		}
+	}
+
+	return diff
+}
+
+// applyPresenceDiff mutates state with diff and returns the join and leave
+// events it produced, ordered by key for determinism
+func applyPresenceDiff(state PresenceState, diff presenceDiff) []PresenceEvent {
+	var events []PresenceEvent
+
+	for _, key := range sortedPresenceKeys(diff.Joins) {
+		joined := diff.Joins[key].Metas
+		current := state[key]
+
+		joinedRefs := presenceRefs(joined)
+		var merged []Presence
+		for _, presence := range current {
+			if !joinedRefs[presence.Ref()] {
+				merged = append(merged, presence)
+			}
+		}
+		state[key] = append(merged, joined...)
+
+		events = append(events, PresenceEvent{
+			Type:    PresenceJoin,
+			Key:     key,
+			Current: current,
+			Changed: joined,
+		})
+	}
+
+	for _, key := range sortedPresenceKeys(diff.Leaves) {
+		current, ok := state[key]
+		if !ok {
+			continue
+		}
+
+		left := diff.Leaves[key].Metas
+		leftRefs := presenceRefs(left)
+		var remaining []Presence
+		for _, presence := range current {
+			if !leftRefs[presence.Ref()] {
+				remaining = append(remaining, presence)
+			}
+		}
+
+		if len(remaining) == 0 {
+			delete(state, key)
+		} else {
+			state[key] = remaining
+		}
+
+		events = append(events, PresenceEvent{
+			Type:    PresenceLeave,
+			Key:     key,
+			Current: remaining,
+			Changed: left,
+		})
+	}
+
+	return events
+}
+
+func presenceRefs(presences []Presence) map[string]bool {
+	refs := make(map[string]bool, len(presences))
+	for _, presence := range presences {
+		refs[presence.Ref()] = true
+	}
+	return refs
+}
+
+func sortedPresenceKeys(entries map[string]presenceEntry) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// clone returns a copy of the state that shares no slices with the original
+func (s PresenceState) clone() PresenceState {
+	out := make(PresenceState, len(s))
+	for key, presences := range s {
+		out[key] = append([]Presence(nil), presences...)
+	}
+	return out
+}
@@ -0,0 +1,384 @@
+package supabaseorm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// phoenixStandIn is a minimal in-process Realtime server speaking the
+// Phoenix channel protocol
+type phoenixStandIn struct {
+	t        *testing.T
+	mu       sync.Mutex
+	refs     int
+	joins    map[string]ChannelConfig
+	tokens   []string
+	httpSent []json.RawMessage
+}
+
+func newPhoenixStandIn(t *testing.T) (*phoenixStandIn, *httptest.Server) {
+	s := &phoenixStandIn{t: t, joins: make(map[string]ChannelConfig)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/realtime/v1/websocket", s.serveSocket)
+	mux.HandleFunc("/realtime/v1/api/broadcast", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.httpSent = append(s.httpSent, body)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return s, server
+}
+
+func (s *phoenixStandIn) serveSocket(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("apikey") != "test-api-key" {
+		http.Error(w, "missing apikey", http.StatusUnauthorized)
+		return
+	}
+
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	send := func(msg phoenixMessage) {
+		data, _ := json.Marshal(msg)
+		_ = conn.WriteMessage(websocket.TextMessage, data)
+	}
+	reply := func(msg phoenixMessage) {
+		send(phoenixMessage{
+			Topic:   msg.Topic,
+			Event:   phxReply,
+			Ref:     msg.Ref,
+			Payload: json.RawMessage(`{"status":"ok","response":{}}`),
+		})
+	}
+
+	for {
+		var msg phoenixMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		switch msg.Event {
+		case phxJoin:
+			var payload struct {
+				Config      ChannelConfig `json:"config"`
+				AccessToken string        `json:"access_token"`
+			}
+			_ = json.Unmarshal(msg.Payload, &payload)
+			s.mu.Lock()
+			s.joins[msg.Topic] = payload.Config
+			s.tokens = append(s.tokens, msg.Topic+" join "+payload.AccessToken)
+			s.mu.Unlock()
+			reply(msg)
+			send(phoenixMessage{Topic: msg.Topic, Event: realtimePresenceState, Payload: json.RawMessage(`{}`)})
+		case realtimeBroadcast:
+			s.mu.Lock()
+			config := s.joins[msg.Topic]
+			s.mu.Unlock()
+			if config.Broadcast.Ack {
+				reply(msg)
+			}
+			if config.Broadcast.Self {
+				send(phoenixMessage{Topic: msg.Topic, Event: realtimeBroadcast, Payload: msg.Payload})
+			}
+		case realtimePresence:
+			var payload struct {
+				Event   string          `json:"event"`
+				Payload json.RawMessage `json:"payload"`
+			}
+			_ = json.Unmarshal(msg.Payload, &payload)
+			s.mu.Lock()
+			key := s.joins[msg.Topic].Presence.Key
+			s.refs++
+			ref := fmt.Sprintf("ref-%d", s.refs)
+			s.mu.Unlock()
+
+			reply(msg)
+
+			var meta map[string]interface{}
+			_ = json.Unmarshal(payload.Payload, &meta)
+			if meta == nil {
+				meta = map[string]interface{}{}
+			}
+			meta["phx_ref"] = ref
+
+			diff := presenceDiff{Joins: map[string]presenceEntry{}, Leaves: map[string]presenceEntry{}}
+			if payload.Event == "track" {
+				diff.Joins[key] = presenceEntry{Metas: []Presence{meta}}
+			} else {
+				diff.Leaves[key] = presenceEntry{Metas: []Presence{{"phx_ref": fmt.Sprintf("ref-%d", s.refs-1)}}}
+			}
+			data, _ := json.Marshal(diff)
+			send(phoenixMessage{Topic: msg.Topic, Event: realtimePresenceDiff, Payload: data})
+		case realtimeAccessToken:
+			var payload struct {
+				AccessToken string `json:"access_token"`
+			}
+			_ = json.Unmarshal(msg.Payload, &payload)
+			s.mu.Lock()
+			s.tokens = append(s.tokens, msg.Topic+" access_token "+payload.AccessToken)
+			s.mu.Unlock()
+		case phxLeave:
+			reply(msg)
+		}
+	}
+}
+
+func TestRealtimeEndpoint(t *testing.T) {
+	client := New("https://example.supabase.co", "test-api-key")
+
+	endpoint, err := client.Realtime().endpoint()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "wss://example.supabase.co/realtime/v1/websocket?apikey=test-api-key&vsn=1.0.0"
+	if endpoint != expected {
+		t.Errorf("Expected endpoint to be %s, got %s", expected, endpoint)
+	}
+}
+
+func TestChannelBroadcastSelfAndAck(t *testing.T) {
+	_, server := newPhoenixStandIn(t)
+	client := New(server.URL, "test-api-key")
+	defer client.Realtime().Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := make(chan string, 1)
+	channel := client.Realtime().Channel("room", ChannelConfig{
+		Broadcast: BroadcastConfig{Self: true, Ack: true},
+	})
+	channel.OnBroadcast("cursor", func(event string, payload json.RawMessage) {
+		received <- string(payload)
+	})
+
+	if err := channel.Subscribe(ctx); err != nil {
+		t.Fatalf("Unexpected error subscribing: %v", err)
+	}
+
+	if err := channel.Send(ctx, Broadcast{Event: "cursor", Payload: map[string]int{"x": 1}}); err != nil {
+		t.Fatalf("Unexpected error sending: %v", err)
+	}
+
+	select {
+	case payload := <-received:
+		if payload != `{"x":1}` {
+			t.Errorf("Expected payload to be %s, got %s", `{"x":1}`, payload)
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected broadcast to be echoed back")
+	}
+}
+
+func TestChannelSendHTTPFallback(t *testing.T) {
+	standIn, server := newPhoenixStandIn(t)
+	client := New(server.URL, "test-api-key")
+
+	channel := client.Realtime().Channel("room", ChannelConfig{})
+	err := channel.Send(context.Background(), Broadcast{Event: "ping", Payload: "hello"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+
+	if len(standIn.httpSent) != 1 {
+		t.Fatalf("Expected 1 HTTP broadcast, got %d", len(standIn.httpSent))
+	}
+
+	expected := `{"messages":[{"topic":"room","event":"ping","payload":"hello"}]}`
+	if string(standIn.httpSent[0]) != expected {
+		t.Errorf("Expected body to be %s, got %s", expected, standIn.httpSent[0])
+	}
+}
+
+func TestChannelPresence(t *testing.T) {
+	_, server := newPhoenixStandIn(t)
+	client := New(server.URL, "test-api-key")
+	defer client.Realtime().Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := make(chan PresenceEvent, 10)
+	channel := client.Realtime().Channel("lobby", ChannelConfig{
+		Presence: PresenceConfig{Key: "user-1"},
+	})
+	channel.OnPresence(PresenceJoin, func(e PresenceEvent) { events <- e })
+	channel.OnPresence(PresenceLeave, func(e PresenceEvent) { events <- e })
+
+	if err := channel.Subscribe(ctx); err != nil {
+		t.Fatalf("Unexpected error subscribing: %v", err)
+	}
+
+	if err := channel.Track(ctx, map[string]string{"status": "online"}); err != nil {
+		t.Fatalf("Unexpected error tracking: %v", err)
+	}
+
+	join := waitPresenceEvent(t, ctx, events)
+	if join.Type != PresenceJoin || join.Key != "user-1" {
+		t.Fatalf("Expected join for user-1, got %s for %s", join.Type, join.Key)
+	}
+	if join.Changed[0]["status"] != "online" {
+		t.Errorf("Expected joined status to be online, got %v", join.Changed[0]["status"])
+	}
+	if len(channel.PresenceState()["user-1"]) != 1 {
+		t.Errorf("Expected 1 presence for user-1, got %d", len(channel.PresenceState()["user-1"]))
+	}
+
+	if err := channel.Untrack(ctx); err != nil {
+		t.Fatalf("Unexpected error untracking: %v", err)
+	}
+
+	leave := waitPresenceEvent(t, ctx, events)
+	if leave.Type != PresenceLeave || leave.Key != "user-1" {
+		t.Fatalf("Expected leave for user-1, got %s for %s", leave.Type, leave.Key)
+	}
+	if _, ok := channel.PresenceState()["user-1"]; ok {
+		t.Error("Expected user-1 to be removed from presence state")
+	}
+}
+
+func waitPresenceEvent(t *testing.T, ctx context.Context, events chan PresenceEvent) PresenceEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for presence event")
+		return PresenceEvent{}
+	}
+}
+
+func TestPresenceSyncStateDiff(t *testing.T) {
+	tracker := newPresenceTracker()
+
+	var joins, leaves []string
+	tracker.on(PresenceJoin, func(e PresenceEvent) { joins = append(joins, e.Key) })
+	tracker.on(PresenceLeave, func(e PresenceEvent) { leaves = append(leaves, e.Key) })
+
+	// A diff received before the first snapshot is buffered
+	tracker.syncDiff(presenceDiff{Joins: map[string]presenceEntry{
+		"c": {Metas: []Presence{{"phx_ref": "3"}}},
+	}})
+	if len(joins) != 0 {
+		t.Fatalf("Expected diff to be buffered, got joins %v", joins)
+	}
+
+	tracker.syncState(map[string]presenceEntry{
+		"a": {Metas: []Presence{{"phx_ref": "1"}}},
+		"b": {Metas: []Presence{{"phx_ref": "2"}}},
+	})
+
+	if fmt.Sprint(joins) != "[a b c]" {
+		t.Errorf("Expected joins [a b c], got %v", joins)
+	}
+
+	tracker.syncState(map[string]presenceEntry{
+		"a": {Metas: []Presence{{"phx_ref": "1"}}},
+		"c": {Metas: []Presence{{"phx_ref": "3"}}},
+	})
+
+	if fmt.Sprint(leaves) != "[b]" {
+		t.Errorf("Expected leaves [b], got %v", leaves)
+	}
+
+	state := tracker.snapshot()
+	if len(state) != 2 || state["b"] != nil {
+		t.Errorf("Expected state to contain a and c, got %v", state)
+	}
+}
+
+func TestChannelUnsubscribeAndReconnect(t *testing.T) {
+	_, server := newPhoenixStandIn(t)
+	client := New(server.URL, "test-api-key")
+	realtime := client.Realtime()
+	defer realtime.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	channel := realtime.Channel("room", ChannelConfig{})
+	if err := channel.Subscribe(ctx); err != nil {
+		t.Fatalf("Unexpected error subscribing: %v", err)
+	}
+	if err := channel.Unsubscribe(ctx); err != nil {
+		t.Fatalf("Unexpected error unsubscribing: %v", err)
+	}
+	realtime.mu.Lock()
+	_, registered := realtime.channels[channel.topic]
+	realtime.mu.Unlock()
+	if registered {
+		t.Error("Expected the unsubscribed channel to be removed")
+	}
+
+	if err := realtime.Close(); err != nil {
+		t.Fatalf("Unexpected error closing: %v", err)
+	}
+	realtime.mu.Lock()
+	conn := realtime.conn
+	realtime.mu.Unlock()
+	if conn != nil {
+		t.Error("Expected Close to clear the connection")
+	}
+
+	// Subscribing again reconnects and registers the channel
+	if err := channel.Subscribe(ctx); err != nil {
+		t.Fatalf("Unexpected error subscribing again: %v", err)
+	}
+	if !channel.isJoined() {
+		t.Error("Expected the channel to be joined on the new connection")
+	}
+}
+
+func TestRealtimeSetAuth(t *testing.T) {
+	standIn, server := newPhoenixStandIn(t)
+	client := New(server.URL, "test-api-key")
+	defer client.Realtime().Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Realtime().Channel("room", ChannelConfig{}).Subscribe(ctx); err != nil {
+		t.Fatalf("Unexpected error subscribing: %v", err)
+	}
+	if err := client.Realtime().SetAuth(ctx, "user-jwt"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := client.Realtime().Channel("private", ChannelConfig{Private: true}).Subscribe(ctx); err != nil {
+		t.Fatalf("Unexpected error subscribing: %v", err)
+	}
+
+	// Frames are handled in order, so the token update arrived before the join
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+	expected := []string{
+		"realtime:room join test-api-key",
+		"realtime:room access_token user-jwt",
+		"realtime:private join user-jwt",
+	}
+	if fmt.Sprint(standIn.tokens) != fmt.Sprint(expected) {
+		t.Errorf("Expected tokens %v, got %v", expected, standIn.tokens)
+	}
+}
@@ -0,0 +1,84 @@
+package supabaseorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Save inserts the model when its primary key is zero and updates it by
+// primary key otherwise. The model is refreshed with the stored row, so
+// server-generated fields such as ids and timestamps are filled in
+func (c *Client) Save(ctx context.Context, model interface{}) error {
+	info, pk, err := recordKey(model)
+	if err != nil {
+		return err
+	}
+
+	q := c.Model(model).WithContext(ctx).Single()
+	q.Header("Prefer", q.preferHeader("return=representation"))
+
+	if reflect.ValueOf(pk).IsZero() {
+		return q.Insert(model)
+	}
+
+	return q.Where(info.PrimaryKey.Column, "eq", pk).Update(model)
+}
+
+// Reload replaces the model with the stored row with the same primary key
+func (c *Client) Reload(ctx context.Context, model interface{}) error {
+	_, pk, err := recordKey(model)
+	if err != nil {
+		return err
+	}
+
+	if reflect.ValueOf(pk).IsZero() {
+		return fmt.Errorf("cannot reload a model without a primary key value")
+	}
+
+	return c.Model(model).WithContext(ctx).Find(pk, model)
+}
+
+// Destroy deletes the stored row with the model's primary key
+func (c *Client) Destroy(ctx context.Context, model interface{}) error {
+	info, pk, err := recordKey(model)
+	if err != nil {
+		return err
+	}
+
+	if reflect.ValueOf(pk).IsZero() {
+		return fmt.Errorf("cannot destroy a model without a primary key value")
+	}
+
+	return c.Model(model).WithContext(ctx).Where(info.PrimaryKey.Column, "eq", pk).Delete()
+}
+
+// FindByID loads the row of T's table with the given primary key
+func FindByID[T any](ctx context.Context, client *Client, id interface{}) (*T, error) {
+	var result T
+	if err := client.Model(&result).WithContext(ctx).Find(id, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// recordKey returns the mapping and primary key value of a model, which
+// must be a pointer to a struct with a pk field
+func recordKey(model interface{}) (*ModelInfo, interface{}, error) {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("model must be a pointer to a struct, got %T", model)
+	}
+
+	info, err := ModelOf(model)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pk, err := info.PrimaryKeyValue(model)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return info, pk, nil
+}
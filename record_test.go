@@ -0,0 +1,132 @@
+package supabaseorm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type recordUser struct {
+	ID        int    `json:"id" supabase:"pk,readonly"`
+	Name      string `json:"name"`
+	UpdatedAt string `json:"updated_at" supabase:"readonly"`
+}
+
+func (recordUser) TableName() string { return "users" }
+
+func TestSaveInsertsNewRecord(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusCreated, `{"id":1,"name":"Ada","updated_at":"2024-01-01"}`))
+	client := server.client()
+
+	user := recordUser{Name: "Ada"}
+	if err := client.Save(context.Background(), &user); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	req := server.requests()[0]
+	if req.method != http.MethodPost {
+		t.Errorf("Expected POST, got %s", req.method)
+	}
+	if req.header.Get("Prefer") != "return=representation" || req.header.Get("Accept") != MediaTypeSingleObject {
+		t.Errorf("Expected a single representation, got Prefer %q Accept %q", req.header.Get("Prefer"), req.header.Get("Accept"))
+	}
+	if _, ok := req.jsonBody()["id"]; ok {
+		t.Error("Expected the readonly primary key not to be sent")
+	}
+	if user.ID != 1 || user.UpdatedAt != "2024-01-01" {
+		t.Errorf("Expected server-generated fields to be refreshed, got %+v", user)
+	}
+}
+
+func TestSaveInsertsWithoutZeroKey(t *testing.T) {
+	type account struct {
+		ID   int    `json:"id" supabase:"pk"`
+		Name string `json:"name"`
+	}
+	RegisterModel(account{}, "accounts")
+
+	server := newCaptureServer(t, respond(http.StatusCreated, `{"id":3,"name":"Ada"}`))
+	client := server.client()
+
+	created := account{Name: "Ada"}
+	if err := client.Save(context.Background(), &created); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	insert := server.requests()[0]
+	if _, ok := insert.jsonBody()["id"]; ok || insert.method != http.MethodPost {
+		t.Errorf("Expected a POST without the zero primary key, got %s %s", insert.method, insert.body)
+	}
+	if created.ID != 3 {
+		t.Errorf("Expected the generated id to be refreshed, got %d", created.ID)
+	}
+
+	// A primary key set by the caller is inserted
+	if err := client.Model(&account{}).Insert(&account{ID: 8, Name: "Grace"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if insert := server.requests()[1]; insert.jsonBody()["id"] != float64(8) {
+		t.Errorf("Expected the primary key to be sent, got %s", insert.body)
+	}
+}
+
+func TestSaveUpdatesExistingRecord(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusOK, `{"id":5,"name":"Grace","updated_at":"2024-02-02"}`))
+	client := server.client()
+
+	user := recordUser{ID: 5, Name: "Grace"}
+	if err := client.Save(context.Background(), &user); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	req := server.requests()[0]
+	if req.method != http.MethodPatch || req.query.Get("id") != "eq.5" {
+		t.Errorf("Expected PATCH by primary key, got %s id=%s", req.method, req.query.Get("id"))
+	}
+	if user.UpdatedAt != "2024-02-02" {
+		t.Errorf("Expected updated_at to be refreshed, got %s", user.UpdatedAt)
+	}
+}
+
+func TestReloadAndDestroy(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusOK, `{"id":5,"name":"Stored"}`))
+	client := server.client()
+
+	user := recordUser{ID: 5, Name: "Local"}
+	if err := client.Reload(context.Background(), &user); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if user.Name != "Stored" {
+		t.Errorf("Expected name to be reloaded, got %s", user.Name)
+	}
+
+	if err := client.Destroy(context.Background(), &user); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if req := server.requests()[1]; req.method != http.MethodDelete || req.query.Get("id") != "eq.5" {
+		t.Errorf("Expected DELETE by primary key, got %s id=%s", req.method, req.query.Get("id"))
+	}
+
+	if err := client.Destroy(context.Background(), &recordUser{}); err == nil {
+		t.Error("Expected an error destroying a record without a primary key")
+	}
+}
+
+func TestFindByID(t *testing.T) {
+	server := newCaptureServer(t, respond(http.StatusOK, `{"id":9,"name":"Alan"}`))
+	client := server.client()
+
+	user, err := FindByID[recordUser](context.Background(), client, 9)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if id := server.requests()[0].query.Get("id"); user.ID != 9 || id != "eq.9" {
+		t.Errorf("Expected user 9, got %+v (id=%s)", user, id)
+	}
+
+	missing := newCaptureServer(t, respond(http.StatusNotAcceptable,
+		`{"code":"PGRST116","details":"The result contains 0 rows","message":"JSON object requested, multiple (or no) rows returned"}`)).client()
+	if _, err := FindByID[recordUser](context.Background(), missing, 10); !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows, got %v", err)
+	}
+}
@@ -0,0 +1,341 @@
+package supabaseorm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNoInteraction is matched by the error of requests that have no
+// recorded interaction in a replayed cassette
+var ErrNoInteraction = errors.New("no recorded interaction matches the request")
+
+// RecordMode selects what WithRecorder does with the requests of a client
+type RecordMode int
+
+const (
+	// Passthrough sends requests without recording them
+	Passthrough RecordMode = iota
+	// Record sends requests and writes them with their responses to the
+	// cassette, replacing its previous content
+	Record
+	// Replay answers requests from the cassette without sending them
+	Replay
+)
+
+// String returns the name of the mode
+func (m RecordMode) String() string {
+	switch m {
+	case Passthrough:
+		return "passthrough"
+	case Record:
+		return "record"
+	case Replay:
+		return "replay"
+	}
+	return fmt.Sprintf("RecordMode(%d)", int(m))
+}
+
+// WithRecorder records the HTTP interactions of the client (table queries,
+// RPC, auth and functions) to the cassette file at path, or replays them
+// from it, so that tests can run without a Supabase project. Keys, tokens
+// and passwords are redacted before they are written.
+//
+// Requests are replayed by method, path, query parameters in any order, and
+// body, ignoring the host and headers. Identical requests get their
+// responses in the order they were recorded. Realtime is not recorded
+func WithRecorder(path string, mode RecordMode) ClientOption {
+	return func(c *Client) {
+		c.recorder = newRecorder(path, mode)
+	}
+}
+
+// cassetteVersion is the format version of cassette files
+const cassetteVersion = 1
+
+// cassette is the content of a cassette file
+type cassette struct {
+	Version      int            `json:"version"`
+	Interactions []*interaction `json:"interactions"`
+}
+
+// interaction is a recorded request and its response
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+
+	// replayed is set once the interaction has answered a request
+	replayed bool
+}
+
+// recordedRequest is a request of a cassette, with secrets redacted
+type recordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// recordedResponse is a response of a cassette, with secrets redacted
+type recordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	// Encoding is "base64" for bodies that are not UTF-8 text
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// recorder records and replays the interactions of a client
+type recorder struct {
+	path string
+	mode RecordMode
+
+	mu       sync.Mutex
+	cassette cassette
+	// err is the error of loading the cassette to replay, returned by every
+	// request; it matches ErrNoInteraction so that it is not retried
+	err error
+}
+
+func newRecorder(path string, mode RecordMode) *recorder {
+	r := &recorder{path: path, mode: mode, cassette: cassette{Version: cassetteVersion}}
+	if mode == Replay {
+		if err := r.load(); err != nil {
+			r.err = fmt.Errorf("%w: %w", ErrNoInteraction, err)
+		}
+	}
+	return r
+}
+
+// load reads the cassette to replay
+func (r *recorder) load() error {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("reading cassette: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return fmt.Errorf("parsing cassette %s: %w", r.path, err)
+	}
+	if r.cassette.Version != cassetteVersion {
+		return fmt.Errorf("cassette %s has unsupported version %d", r.path, r.cassette.Version)
+	}
+	return nil
+}
+
+// save writes the cassette; r.mu must be held
+func (r *recorder) save() error {
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return nil
+}
+
+// recordTransport records or replays requests in place of the network
+type recordTransport struct {
+	next     http.RoundTripper
+	recorder *recorder
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch t.recorder.mode {
+	case Record:
+		return t.record(req)
+	case Replay:
+		return t.replay(req)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// record sends req and adds it to the cassette with its response
+func (t *recordTransport) record(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		// Network errors are not recorded, so they are not replayed either
+		return nil, err
+	}
+
+	// The response is buffered to be recorded, including streamed results
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	recorded := &interaction{
+		Request: recordedRequest{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  canonicalQuery(req.URL.RawQuery),
+			Header: recordedHeader(req.Header),
+			Body:   redactBody(string(body)),
+		},
+		Response: recordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     recordedHeader(resp.Header),
+		},
+	}
+	if utf8.Valid(respBody) {
+		recorded.Response.Body = redactBody(string(respBody))
+	} else {
+		recorded.Response.Body = base64.StdEncoding.EncodeToString(respBody)
+		recorded.Response.Encoding = "base64"
+	}
+
+	t.recorder.mu.Lock()
+	defer t.recorder.mu.Unlock()
+	t.recorder.cassette.Interactions = append(t.recorder.cassette.Interactions, recorded)
+	if err := t.recorder.save(); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replay answers req with the first interaction of the cassette that matches
+// it and has not been replayed yet
+func (t *recordTransport) replay(req *http.Request) (*http.Response, error) {
+	if t.recorder.err != nil {
+		return nil, t.recorder.err
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	query := canonicalQuery(req.URL.RawQuery)
+	key := canonicalBody(redactBody(string(body)))
+
+	t.recorder.mu.Lock()
+	defer t.recorder.mu.Unlock()
+	for _, recorded := range t.recorder.cassette.Interactions {
+		if recorded.replayed ||
+			recorded.Request.Method != req.Method ||
+			recorded.Request.Path != req.URL.Path ||
+			recorded.Request.Query != query ||
+			canonicalBody(recorded.Request.Body) != key {
+			continue
+		}
+
+		respBody := []byte(recorded.Response.Body)
+		if recorded.Response.Encoding == "base64" {
+			if respBody, err = base64.StdEncoding.DecodeString(recorded.Response.Body); err != nil {
+				return nil, fmt.Errorf("decoding recorded response body: %w", err)
+			}
+		}
+		recorded.replayed = true
+
+		header := recorded.Response.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.Response.StatusCode, http.StatusText(recorded.Response.StatusCode)),
+			StatusCode:    recorded.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, redactURL(req.URL))
+}
+
+// readRequestBody returns the body of req, leaving it readable
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// recordedHeader returns header with secrets redacted and cookies removed
+func recordedHeader(header http.Header) http.Header {
+	recorded := make(http.Header, len(header))
+	for key, values := range header {
+		key = http.CanonicalHeaderKey(key)
+		switch {
+		case key == "Cookie" || key == "Set-Cookie":
+		case sensitiveHeaders[key]:
+			recorded[key] = []string{redacted}
+		default:
+			recorded[key] = append([]string(nil), values...)
+		}
+	}
+	return recorded
+}
+
+// canonicalQuery returns the decoded query parameters sorted by name and
+// value, with secrets redacted
+func canonicalQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+
+	parts := make([]string, 0, len(params))
+	for key, values := range params {
+		for _, value := range values {
+			if sensitiveParams[strings.ToLower(key)] {
+				value = redacted
+			}
+			parts = append(parts, key+"="+value)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "&")
+}
+
+// canonicalBody returns a JSON body with its object keys sorted and without
+// whitespace, or any other body unchanged
+func canonicalBody(body string) string {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return body
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return string(canonical)
+}
@@ -0,0 +1,122 @@
+package supabaseorm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// liveHandler answers like a live project for the recorder to capture
+func liveHandler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/auth/v1/token":
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"live-access-token","refresh_token":"live-refresh-token","token_type":"bearer","user":{"id":"u1","email":"jane@example.com"}}`))
+	case r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Range", "0-0/1")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Write([]byte(`[{"code":"FR","name":"France"}]`))
+	case r.Method == http.MethodPost:
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"code":"DE"`) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	server := newCaptureServer(t, liveHandler)
+	path := filepath.Join(t.TempDir(), "cassettes", "countries.json")
+	ctx := context.Background()
+
+	client := New(server.URL, "live-api-key", WithRecorder(path, Record))
+	var rows []map[string]interface{}
+	if err := client.Table("countries").Where("code", "eq", "FR").Where("name", "like", "Fr*").Get(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := client.Table("countries").Insert(map[string]interface{}{"code": "DE", "name": "Germany"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	session, err := client.Auth().SignInWithPassword(ctx, SignInRequest{Email: "jane@example.com", Password: "hunter2"})
+	if err != nil || session.AccessToken != "live-access-token" {
+		t.Fatalf("Unexpected session %+v, %v", session, err)
+	}
+	if server.count() != 3 {
+		t.Fatalf("Expected 3 requests to be sent, got %d", server.count())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected the cassette to be written: %v", err)
+	}
+	for _, secret := range []string{"live-api-key", "hunter2", "live-access-token", "live-refresh-token", "session=abc"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %q to be stripped from the cassette", secret)
+		}
+	}
+
+	// Filters in another order and a body with another key order match
+	replay := New("http://127.0.0.1:1", "other-key", WithRecorder(path, Replay))
+	rows = nil
+	if err := replay.Table("countries").Where("name", "like", "Fr*").Where("code", "eq", "FR").Get(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["name"] != "France" {
+		t.Errorf("Unexpected replayed rows %v", rows)
+	}
+	country := struct {
+		Name string `json:"name"`
+		Code string `json:"code"`
+	}{"Germany", "DE"}
+	if err := replay.Table("countries").Insert(&country); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	session, err = replay.Auth().SignInWithPassword(ctx, SignInRequest{Email: "jane@example.com", Password: "hunter2"})
+	if err != nil || session.User.Email != "jane@example.com" || session.AccessToken != redacted {
+		t.Errorf("Unexpected replayed session %+v, %v", session, err)
+	}
+	if server.count() != 3 {
+		t.Errorf("Expected replayed requests not to be sent, got %d requests", server.count())
+	}
+
+	// Each interaction is replayed once
+	err = replay.Table("countries").Where("code", "eq", "FR").Where("name", "like", "Fr*").Get(&rows)
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("Expected ErrNoInteraction for a replayed interaction, got %v", err)
+	}
+	err = replay.Table("countries").Insert(map[string]interface{}{"code": "IT"})
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("Expected ErrNoInteraction for another body, got %v", err)
+	}
+}
+
+func TestRecorderModes(t *testing.T) {
+	server := newCaptureServer(t, liveHandler)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	client := server.client(WithRecorder(path, Passthrough))
+	if _, err := client.Table("countries").Count(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected passthrough not to write a cassette, got %v", err)
+	}
+
+	client = server.client(WithRecorder(path, Replay), WithRetry(RetryPolicy{MaxAttempts: 3}))
+	if _, err := client.Table("countries").Count(); !errors.Is(err, os.ErrNotExist) || !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("Expected a missing cassette error, got %v", err)
+	}
+	if server.count() != 1 {
+		t.Errorf("Expected 1 request to be sent, got %d", server.count())
+	}
+}
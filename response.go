@@ -5,11 +5,15 @@ import (
 )
 
 // Response wraps the Supabase API response
+// It is also the response seen by Middleware
 type Response struct {
 	StatusCode int
 	Headers    map[string]string
 	Body       []byte
 	Error      error
+
+	// raw is the response received, if the response was not made up by middleware
+	raw *resty.Response
 }
 
 // NewResponse creates a new Response from a resty.Response
@@ -43,9 +47,7 @@ func (r *Response) IsError() bool {
 	return r.StatusCode >= 400
 }
 
-// GetContentRange parses the Content-Range header
+// GetContentRange parses the Content-Range header (e.g., "0-9/42")
 func (r *Response) GetContentRange() (int, int, int) {
-	// Parse Content-Range header (e.g., "0-9/42")
-	// This is a placeholder - in a real implementation, you'd parse the header
-	return 0, 0, 0
+	return ParseContentRange(r.Headers["Content-Range"])
 }
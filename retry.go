@@ -0,0 +1,227 @@
+package supabaseorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxAttempts is the number of attempts used when RetryPolicy.MaxAttempts is not set
+const DefaultMaxAttempts = 3
+
+// RetryPolicy configures the retries of failed requests
+//
+// By default only idempotent requests are retried: GET, HEAD, OPTIONS and
+// PUT, upserts, and queries marked with QueryBuilder.Idempotent
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	MaxAttempts int
+	// Backoff returns the wait before each retry, defaulting to
+	// ExponentialBackoff(100*time.Millisecond, 5*time.Second)
+	Backoff BackoffFunc
+	// RetryOn reports whether a failed attempt is retried, defaulting to
+	// RetryOnTransient
+	RetryOn func(resp *http.Response, err error) bool
+	// RespectRetryAfter waits for the Retry-After header of a response
+	// instead of the backoff, when present
+	RespectRetryAfter bool
+	// RetryNonIdempotent also retries POST, PATCH and DELETE requests
+	RetryNonIdempotent bool
+}
+
+// BackoffFunc returns the wait before the given retry, starting at 1
+type BackoffFunc func(retry int) time.Duration
+
+// ExponentialBackoff doubles the wait from base up to max, with full jitter
+func ExponentialBackoff(base, max time.Duration) BackoffFunc {
+	return func(retry int) time.Duration {
+		wait := base
+		for i := 1; i < retry && wait < max; i++ {
+			wait *= 2
+		}
+		if wait > max {
+			wait = max
+		}
+		if wait <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(wait) + 1))
+	}
+}
+
+// ConstantBackoff waits the same duration before every retry
+func ConstantBackoff(wait time.Duration) BackoffFunc {
+	return func(int) time.Duration {
+		return wait
+	}
+}
+
+// RetryOnTransient retries network errors and 408, 429 and 5xx responses
+func RetryOnTransient(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+			!errors.Is(err, ErrNoInteraction)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return resp.StatusCode >= 500
+}
+
+// WithRetry retries failed requests of the client according to policy
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = &policy
+	}
+}
+
+// RetryError is returned when a request still fails with a network error
+// after more than one attempt
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Idempotent marks the query as safe to retry, e.g. a read-only RPC
+func (q *QueryBuilder) Idempotent() *QueryBuilder {
+	q.idempotent = true
+	return q
+}
+
+type idempotentKey struct{}
+
+type attemptsKey struct{}
+
+// withAttemptCounter returns a context in which the retry transport
+// records the number of attempts in attempts
+func withAttemptCounter(ctx context.Context, attempts *int) context.Context {
+	return context.WithValue(ctx, attemptsKey{}, attempts)
+}
+
+// retryTransport retries requests according to a RetryPolicy
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+}
+
+func newRetryTransport(next http.RoundTripper, policy RetryPolicy) *retryTransport {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultMaxAttempts
+	}
+	if policy.Backoff == nil {
+		policy.Backoff = ExponentialBackoff(100*time.Millisecond, 5*time.Second)
+	}
+	if policy.RetryOn == nil {
+		policy.RetryOn = RetryOnTransient
+	}
+	return &retryTransport{next: next, policy: policy}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.retryable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			attemptReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+		if attempts, ok := ctx.Value(attemptsKey{}).(*int); ok {
+			*attempts = attempt
+		}
+
+		if attempt >= t.policy.MaxAttempts || !t.policy.RetryOn(resp, err) {
+			if err != nil && attempt > 1 {
+				err = &RetryError{Attempts: attempt, Err: err}
+			}
+			return resp, err
+		}
+
+		wait := t.policy.Backoff(attempt)
+		if resp != nil {
+			if after, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok && t.policy.RespectRetryAfter {
+				wait = after
+			}
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// retryable reports whether the policy allows retrying req
+func (t *retryTransport) retryable(req *http.Request) bool {
+	// Bodies that cannot be replayed cannot be retried
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut:
+		return true
+	}
+
+	if marked, _ := req.Context().Value(idempotentKey{}).(bool); marked {
+		return true
+	}
+
+	// Upserts can be repeated without creating duplicates
+	if req.Method == http.MethodPost && strings.Contains(req.Header.Get("Prefer"), "resolution=") {
+		return true
+	}
+
+	return t.policy.RetryNonIdempotent
+}
+
+// parseRetryAfter parses a Retry-After header in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
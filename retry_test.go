@@ -0,0 +1,121 @@
+package supabaseorm
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flaky fails the first failures requests with status
+func flaky(failures int32, status int) http.HandlerFunc {
+	var calls int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			w.Write([]byte(`{"message":"schema cache reloading"}`))
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`[{"id":1}]`))
+		}
+	}
+}
+
+func fastRetry() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 3, Backoff: ConstantBackoff(time.Millisecond)}
+}
+
+func TestRetryIdempotentReads(t *testing.T) {
+	server := newCaptureServer(t, flaky(2, http.StatusServiceUnavailable))
+	client := server.client(WithRetry(fastRetry()))
+
+	var rows []map[string]interface{}
+	if err := client.Table("users").Get(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if server.count() != 3 || len(rows) != 1 {
+		t.Errorf("Expected success on the third attempt, got %d calls and %v", server.count(), rows)
+	}
+}
+
+func TestRetryReportsAttempts(t *testing.T) {
+	server := newCaptureServer(t, flaky(5, http.StatusTooManyRequests))
+	client := server.client(WithRetry(fastRetry()))
+
+	var rows []map[string]interface{}
+	err := client.Table("users").Get(&rows)
+
+	var apiErr *PostgrestError
+	if !errors.As(err, &apiErr) || apiErr.Attempts != 3 {
+		t.Fatalf("Expected an API error after 3 attempts, got %v", err)
+	}
+	if server.count() != 3 {
+		t.Errorf("Expected 3 calls, got %d", server.count())
+	}
+}
+
+func TestRetrySkipsNonIdempotent(t *testing.T) {
+	server := newCaptureServer(t, flaky(1, http.StatusServiceUnavailable))
+	client := server.client(WithRetry(fastRetry()))
+
+	if err := client.Table("users").Insert(map[string]interface{}{"name": "Ada"}); err == nil {
+		t.Error("Expected the insert to fail without retrying")
+	}
+	if server.count() != 1 {
+		t.Errorf("Expected 1 call, got %d", server.count())
+	}
+
+	// Upserts and queries marked idempotent are retried
+	server = newCaptureServer(t, flaky(1, http.StatusServiceUnavailable))
+	client = server.client(WithRetry(fastRetry()))
+	q := client.Table("users")
+	q.Header("Prefer", "resolution=merge-duplicates")
+	if err := q.Insert(map[string]interface{}{"id": 1}); err != nil {
+		t.Errorf("Expected the upsert to be retried, got %v", err)
+	}
+
+	server = newCaptureServer(t, flaky(1, http.StatusServiceUnavailable))
+	client = server.client(WithRetry(fastRetry()))
+	if err := client.Table("users").Raw("select 1").Idempotent().Get(nil); err != nil {
+		t.Errorf("Expected the idempotent RPC to be retried, got %v", err)
+	}
+
+	// RetryNonIdempotent opts in for all writes
+	policy := fastRetry()
+	policy.RetryNonIdempotent = true
+	server = newCaptureServer(t, flaky(1, http.StatusServiceUnavailable))
+	client = server.client(WithRetry(policy))
+	if err := client.Table("users").Insert(map[string]interface{}{"name": "Ada"}); err != nil {
+		t.Errorf("Expected the insert to be retried, got %v", err)
+	}
+}
+
+func TestRetryNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	client := New(url, "test-api-key", WithRetry(fastRetry()))
+
+	var retryErr *RetryError
+	if err := client.Table("users").Get(nil); !errors.As(err, &retryErr) || retryErr.Attempts != 3 {
+		t.Errorf("Expected a RetryError after 3 attempts, got %v", err)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	for retry := 1; retry <= 10; retry++ {
+		if wait := backoff(retry); wait < 0 || wait > 50*time.Millisecond {
+			t.Errorf("Retry %d: wait %v out of bounds", retry, wait)
+		}
+	}
+
+	if wait, ok := parseRetryAfter("2"); !ok || wait != 2*time.Second {
+		t.Errorf("Expected 2s, got %v", wait)
+	}
+}
@@ -0,0 +1,215 @@
+package supabaseorm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// ErrUnscopedWrite is returned when a write would not be restricted by one
+// of the client's scopes
+var ErrUnscopedWrite = errors.New("write is not restricted by scope")
+
+// ScopeFunc adds the filters of a scope to a query
+type ScopeFunc func(q *QueryBuilder)
+
+type scope struct {
+	name string
+	fn   ScopeFunc
+}
+
+// WithScope returns a client whose table queries are always filtered by fn
+//
+//	tenant := client.WithScope("tenant", func(q *QueryBuilder) {
+//		q.Where("tenant_id", "eq", tenantID)
+//	})
+//
+// Only the filters added by fn are used. Its eq filters are also set on
+// inserted rows, and inserting a row with a different non-zero value fails.
+// Updates that set one of these columns to another value fail with
+// ErrUnscopedWrite, as do updates and deletes if fn adds no filter.
+// A scope with the same name as an existing one replaces it
+func (c *Client) WithScope(name string, fn ScopeFunc) *Client {
+	clone := *c
+	clone.scopes = nil
+	for _, s := range c.scopes {
+		if s.name != name {
+			clone.scopes = append(clone.scopes, s)
+		}
+	}
+	clone.scopes = append(clone.scopes, scope{name: name, fn: fn})
+	return &clone
+}
+
+// Unscoped removes the named scopes from the query, or all of them if no
+// names are given
+func (q *QueryBuilder) Unscoped(names ...string) *QueryBuilder {
+	if len(names) == 0 {
+		q.scopes = nil
+		return q
+	}
+
+	remove := make(map[string]bool, len(names))
+	for _, name := range names {
+		remove[name] = true
+	}
+
+	var kept []scope
+	for _, s := range q.scopes {
+		if !remove[s.name] {
+			kept = append(kept, s)
+		}
+	}
+	q.scopes = kept
+	return q
+}
+
+// scopeFilters returns the filters added by each of the query's scopes
+func (q *QueryBuilder) scopeFilters() [][]filter {
+	filters := make([][]filter, len(q.scopes))
+	for i, s := range q.scopes {
+		scoped := &QueryBuilder{client: q.client, tableName: q.tableName}
+		s.fn(scoped)
+		filters[i] = scoped.filters
+	}
+	return filters
+}
+
+// checkScopes refuses updates and deletes that a scope does not restrict
+func (q *QueryBuilder) checkScopes() error {
+	if q.method != http.MethodPatch && q.method != http.MethodDelete {
+		return nil
+	}
+
+	for i, filters := range q.scopeFilters() {
+		if len(filters) == 0 {
+			return fmt.Errorf("%w %q", ErrUnscopedWrite, q.scopes[i].name)
+		}
+	}
+	return nil
+}
+
+// checkScopedUpdate refuses updates that set a column pinned by a scope to
+// another value, which would move rows out of the scope
+func (q *QueryBuilder) checkScopedUpdate(body interface{}) error {
+	if q.method != http.MethodPatch || body == nil {
+		return nil
+	}
+	values := q.scopeValues()
+	if len(values) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return fmt.Errorf("scoped updates must be JSON objects: %w", err)
+	}
+
+	for _, column := range sortedKeys(values) {
+		current, ok := object[column]
+		if !ok {
+			continue
+		}
+		encoded, err := json.Marshal(values[column])
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(bytes.TrimSpace(current), encoded) {
+			return fmt.Errorf("%w: cannot set column %s to %s, scope requires %s", ErrUnscopedWrite, column, current, encoded)
+		}
+	}
+	return nil
+}
+
+// scopeValues returns the columns that the scopes' eq filters pin to a value
+func (q *QueryBuilder) scopeValues() map[string]interface{} {
+	var values map[string]interface{}
+	for _, filters := range q.scopeFilters() {
+		for _, f := range filters {
+			if f.isOr || f.isComplex || normalizeOperator(f.operator) != "eq" {
+				continue
+			}
+			if values == nil {
+				values = make(map[string]interface{})
+			}
+			values[f.column] = f.value
+		}
+	}
+	return values
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// scopeBody sets the scope values on an insert body, a row or a slice of rows
+func (q *QueryBuilder) scopeBody(body interface{}) (interface{}, error) {
+	values := q.scopeValues()
+	if len(values) == 0 || body == nil {
+		return body, nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var rows []json.RawMessage
+		if err := json.Unmarshal(trimmed, &rows); err != nil {
+			return nil, err
+		}
+		for i, row := range rows {
+			if rows[i], err = setColumns(row, values); err != nil {
+				return nil, fmt.Errorf("row %d: %w", i, err)
+			}
+		}
+		return json.Marshal(rows)
+	}
+
+	return setColumns(data, values)
+}
+
+// setColumns sets values on a JSON object. Columns that already hold a
+// different non-zero value are an error
+func setColumns(row json.RawMessage, values map[string]interface{}) (json.RawMessage, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(row, &object); err != nil {
+		return nil, fmt.Errorf("scoped rows must be JSON objects: %w", err)
+	}
+
+	for column, value := range values {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if current, ok := object[column]; ok && !isZeroJSON(current) && !bytes.Equal(current, encoded) {
+			return nil, fmt.Errorf("%w: column %s is %s, not %s", ErrUnscopedWrite, column, current, encoded)
+		}
+		object[column] = encoded
+	}
+
+	return json.Marshal(object)
+}
+
+// isZeroJSON reports whether data is null or the zero value of its type
+func isZeroJSON(data json.RawMessage) bool {
+	switch string(bytes.TrimSpace(data)) {
+	case "null", `""`, "0", "false":
+		return true
+	}
+	return false
+}
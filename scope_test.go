@@ -0,0 +1,141 @@
+package supabaseorm
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func tenantScope(tenantID interface{}) ScopeFunc {
+	return func(q *QueryBuilder) {
+		if tenantID != nil {
+			q.Where("tenant_id", "eq", tenantID)
+		}
+	}
+}
+
+func TestScopeFilters(t *testing.T) {
+	server := newCaptureServer(t, softDeleteHandler)
+	client := server.client()
+	tenant := client.WithScope("tenant", tenantScope(7))
+
+	var rows []map[string]interface{}
+	tenant.Table("posts").Where("id", "eq", 1).OrWhere("id", "eq", 2).Get(&rows)
+	tenant.Table("posts").Unscoped().Get(&rows)
+	client.Table("posts").Get(&rows)
+
+	if server.requests()[0].query.Get("tenant_id") != "eq.7" || server.requests()[0].query.Get("or") != "(id.eq.1,id.eq.2)" {
+		t.Errorf("Expected the scope to combine with the query filters, got %v", server.requests()[0].query)
+	}
+	if server.requests()[1].query.Has("tenant_id") || server.requests()[2].query.Has("tenant_id") {
+		t.Error("Expected Unscoped and the original client not to be scoped")
+	}
+}
+
+func TestScopeInserts(t *testing.T) {
+	server := newCaptureServer(t, softDeleteHandler)
+	client := server.client()
+	tenant := client.WithScope("tenant", tenantScope(7))
+
+	if err := tenant.Table("posts").Insert(map[string]interface{}{"title": "Hello"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if server.requests()[0].jsonBody()["tenant_id"] != float64(7) {
+		t.Errorf("Expected the scoped column to be set, got %v", server.requests()[0].body)
+	}
+
+	err := tenant.Table("posts").Insert(map[string]interface{}{"title": "Hello", "tenant_id": 8})
+	if !errors.Is(err, ErrUnscopedWrite) {
+		t.Errorf("Expected ErrUnscopedWrite for another tenant, got %v", err)
+	}
+	if server.count() != 1 {
+		t.Errorf("Expected no request for another tenant, got %d", server.count())
+	}
+}
+
+func TestScopeInsertMany(t *testing.T) {
+	handler := &bulkServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	tenant := New(server.URL, "test-api-key").WithScope("tenant", tenantScope("acme"))
+
+	rows := []map[string]interface{}{{"name": "a"}, {"name": "b", "tenant_id": ""}}
+	if _, err := tenant.Table("users").InsertMany(rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, row := range handler.batches[0] {
+		if row["tenant_id"] != "acme" {
+			t.Errorf("Expected every row to be scoped, got %v", row)
+		}
+	}
+	if !strings.Contains(handler.columns[0], "tenant_id") {
+		t.Errorf("Expected tenant_id in the columns, got %s", handler.columns[0])
+	}
+}
+
+func TestScopeRefusesUnscopedWrites(t *testing.T) {
+	server := newCaptureServer(t, softDeleteHandler)
+	client := server.client()
+	unknown := client.WithScope("tenant", tenantScope(nil))
+
+	if err := unknown.Table("posts").Where("id", "eq", 1).Delete(); !errors.Is(err, ErrUnscopedWrite) {
+		t.Errorf("Expected ErrUnscopedWrite for delete, got %v", err)
+	}
+	if err := unknown.Table("posts").Where("id", "eq", 1).Update(map[string]interface{}{"title": "x"}); !errors.Is(err, ErrUnscopedWrite) {
+		t.Errorf("Expected ErrUnscopedWrite for update, got %v", err)
+	}
+	if server.count() != 0 {
+		t.Errorf("Expected no requests, got %d", server.count())
+	}
+
+	if err := unknown.Table("posts").Unscoped("tenant").Where("id", "eq", 1).Delete(); err != nil {
+		t.Errorf("Expected Unscoped to allow the delete, got %v", err)
+	}
+	if server.count() != 1 || server.requests()[0].method != http.MethodDelete {
+		t.Errorf("Expected the unscoped delete to be sent, got %+v", server.requests())
+	}
+}
+
+func TestScopeUpdates(t *testing.T) {
+	server := newCaptureServer(t, softDeleteHandler)
+	client := server.client()
+	tenant := client.WithScope("tenant", tenantScope(1))
+
+	err := tenant.Table("posts").Where("id", "eq", 5).Update(map[string]interface{}{"tenant_id": 2})
+	if !errors.Is(err, ErrUnscopedWrite) {
+		t.Errorf("Expected ErrUnscopedWrite moving a row to another tenant, got %v", err)
+	}
+
+	type post struct {
+		Title    string `json:"title"`
+		TenantID int    `json:"tenant_id"`
+	}
+	moved := &post{Title: "Hello", TenantID: 2}
+	if err := tenant.Table("posts").Where("id", "eq", 5).UpdateFields(moved, "tenant_id"); !errors.Is(err, ErrUnscopedWrite) {
+		t.Errorf("Expected ErrUnscopedWrite from UpdateFields, got %v", err)
+	}
+
+	kept := &post{Title: "Hello", TenantID: 1}
+	changes, err := NewChangeset(kept)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	kept.TenantID = 0
+	if err := tenant.Table("posts").Where("id", "eq", 5).UpdateChanges(changes); !errors.Is(err, ErrUnscopedWrite) {
+		t.Errorf("Expected ErrUnscopedWrite from UpdateChanges, got %v", err)
+	}
+	if server.count() != 0 {
+		t.Fatalf("Expected no request for a write leaving the scope, got %d", server.count())
+	}
+
+	// Keeping the scoped value is allowed
+	if err := tenant.Table("posts").Where("id", "eq", 5).Update(map[string]interface{}{"title": "Hi", "tenant_id": 1}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if server.count() != 1 || server.requests()[0].query.Get("tenant_id") != "eq.1" {
+		t.Errorf("Expected a scoped update, got %v", server.requests())
+	}
+}
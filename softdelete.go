@@ -0,0 +1,81 @@
+package supabaseorm
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// trashedMode selects which rows of a soft delete table a query sees
+type trashedMode int
+
+const (
+	// excludeTrashed only sees rows that are not deleted (the default)
+	excludeTrashed trashedMode = iota
+	// withTrashed sees all rows
+	withTrashed
+	// onlyTrashed only sees deleted rows
+	onlyTrashed
+)
+
+// WithTrashed includes soft deleted rows in the query
+func (q *QueryBuilder) WithTrashed() *QueryBuilder {
+	q.trashed = withTrashed
+	return q
+}
+
+// OnlyTrashed restricts the query to soft deleted rows
+func (q *QueryBuilder) OnlyTrashed() *QueryBuilder {
+	q.trashed = onlyTrashed
+	return q
+}
+
+// Restore clears the soft delete column of the matching deleted rows
+func (q *QueryBuilder) Restore() error {
+	if q.softDelete == "" {
+		return fmt.Errorf("table %s has no soft delete column", q.tableName)
+	}
+
+	q.trashed = onlyTrashed
+	q.method = http.MethodPatch
+	return q.execute(map[string]interface{}{q.softDelete: nil})
+}
+
+// ForceDelete permanently deletes the matching rows, deleted or not
+func (q *QueryBuilder) ForceDelete() error {
+	q.trashed = withTrashed
+	q.method = http.MethodDelete
+	return q.execute(nil)
+}
+
+// softDeleteRows sets the soft delete column of the matching rows to the
+// current time, running the delete hooks around the update
+func (q *QueryBuilder) softDeleteRows() error {
+	ctx := q.context()
+	if err := runHook(ctx, q.instance, BeforeDeleter.BeforeDelete); err != nil {
+		return err
+	}
+
+	q.method = http.MethodPatch
+	if err := q.execute(map[string]interface{}{q.softDelete: time.Now().UTC()}); err != nil {
+		return err
+	}
+
+	return runHook(ctx, q.instance, AfterDeleter.AfterDelete)
+}
+
+// softDeleteFilter returns the filter that hides or selects deleted rows
+func (q *QueryBuilder) softDeleteFilter() (filter, bool) {
+	if q.softDelete == "" || q.rawQuery != "" {
+		return filter{}, false
+	}
+
+	switch q.trashed {
+	case excludeTrashed:
+		return filter{column: q.softDelete, operator: "is", value: nil}, true
+	case onlyTrashed:
+		return filter{column: q.softDelete, operator: "not.is", value: nil}, true
+	}
+
+	return filter{}, false
+}
@@ -0,0 +1,99 @@
+package supabaseorm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// softDeleteHandler answers reads with a counted empty page and writes with 200
+func softDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		w.Header().Set("Content-Range", "0-0/1")
+		w.Write([]byte(`[]`))
+	}
+}
+
+type softDeletePost struct {
+	ID        int        `json:"id" supabase:"pk,readonly"`
+	Title     string     `json:"title"`
+	DeletedAt *time.Time `json:"deleted_at" supabase:"softdelete"`
+}
+
+func (softDeletePost) TableName() string { return "posts" }
+
+func TestSoftDeleteFilters(t *testing.T) {
+	server := newCaptureServer(t, softDeleteHandler)
+	client := server.client(WithSoftDelete("posts", "deleted_at"))
+
+	var posts []map[string]interface{}
+	client.Table("posts").Where("author_id", "eq", 1).Get(&posts)
+	client.Table("posts").WithTrashed().Get(&posts)
+	client.Table("posts").OnlyTrashed().Get(&posts)
+	client.Table("posts").Count()
+	client.Table("comments").Get(&posts)
+
+	expected := []string{"is.null", "", "not.is.null", "is.null", ""}
+	for i, want := range expected {
+		if got := server.requests()[i].query.Get("deleted_at"); got != want {
+			t.Errorf("Request %d: expected deleted_at=%q, got %q", i, want, got)
+		}
+	}
+	if server.requests()[0].query.Get("author_id") != "eq.1" {
+		t.Errorf("Expected custom filters to combine with soft delete, got %v", server.requests()[0].query)
+	}
+}
+
+func TestSoftDeleteWrites(t *testing.T) {
+	server := newCaptureServer(t, softDeleteHandler)
+	client := server.client(WithSoftDelete("posts", "deleted_at"))
+
+	if err := client.Table("posts").Where("id", "eq", 1).Delete(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := client.Table("posts").Where("id", "eq", 1).Restore(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := client.Table("posts").Where("id", "eq", 1).ForceDelete(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	del := server.requests()[0]
+	if del.method != http.MethodPatch || del.query.Get("deleted_at") != "is.null" {
+		t.Errorf("Expected Delete to patch undeleted rows, got %s %v", del.method, del.query)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, del.jsonBody()["deleted_at"].(string)); err != nil {
+		t.Errorf("Expected a deletion timestamp, got %v", del.jsonBody())
+	}
+
+	restore := server.requests()[1]
+	if restore.method != http.MethodPatch || restore.query.Get("deleted_at") != "not.is.null" || restore.jsonBody()["deleted_at"] != nil {
+		t.Errorf("Expected Restore to clear deleted rows, got %s %v %v", restore.method, restore.query, restore.jsonBody())
+	}
+
+	force := server.requests()[2]
+	if force.method != http.MethodDelete || force.query.Has("deleted_at") {
+		t.Errorf("Expected ForceDelete to delete all matching rows, got %s %v", force.method, force.query)
+	}
+
+	if err := client.Table("comments").Restore(); err == nil {
+		t.Error("Expected Restore to fail without a soft delete column")
+	}
+}
+
+func TestSoftDeleteModel(t *testing.T) {
+	server := newCaptureServer(t, softDeleteHandler)
+	client := server.client()
+
+	var posts []softDeletePost
+	client.Model(&posts).Get(&posts)
+	client.Destroy(context.Background(), &softDeletePost{ID: 3})
+
+	if server.requests()[0].query.Get("deleted_at") != "is.null" {
+		t.Errorf("Expected the model's soft delete column to filter reads, got %v", server.requests()[0].query)
+	}
+	if server.requests()[1].method != http.MethodPatch || server.requests()[1].query.Get("id") != "eq.3" {
+		t.Errorf("Expected Destroy to soft delete, got %s %v", server.requests()[1].method, server.requests()[1].query)
+	}
+}
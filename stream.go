@@ -0,0 +1,136 @@
+package supabaseorm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Rows is a streaming iterator over query results
+// Rows are decoded one at a time, so memory use does not grow with the
+// size of the result
+//
+//	rows, err := client.Table("events").Rows(ctx)
+//	if err != nil { ... }
+//	defer rows.Close()
+//	for rows.Next() {
+//		var e Event
+//		if err := rows.Scan(&e); err != nil { ... }
+//	}
+//	if err := rows.Err(); err != nil { ... }
+type Rows struct {
+	ctx     context.Context
+	body    io.ReadCloser
+	dec     *json.Decoder
+	current json.RawMessage
+	err     error
+	closed  bool
+}
+
+// Rows executes the query and returns an iterator over the result rows
+// The caller must call Close, which releases the connection
+func (q *QueryBuilder) Rows(ctx context.Context) (*Rows, error) {
+	q.method = http.MethodGet
+
+	req := q.newRequest().
+		SetContext(ctx).
+		SetDoNotParseResponse(true)
+
+	resp, err := q.send(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := &Rows{
+		ctx:  ctx,
+		body: resp.RawBody(),
+		dec:  json.NewDecoder(resp.RawBody()),
+	}
+
+	tok, err := rows.dec.Token()
+	if err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("failed to read result: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		rows.Close()
+		return nil, fmt.Errorf("expected a JSON array, got %v", tok)
+	}
+
+	return rows, nil
+}
+
+// Next advances to the next row and reports whether there is one
+// The body is closed once the last row has been read
+func (r *Rows) Next() bool {
+	if r.closed || r.err != nil {
+		return false
+	}
+
+	if !r.dec.More() {
+		if _, err := r.dec.Token(); err != nil {
+			r.err = err
+		}
+		r.Close()
+		return false
+	}
+
+	r.current = nil
+	if err := r.dec.Decode(&r.current); err != nil {
+		r.err = err
+		r.Close()
+		return false
+	}
+
+	return true
+}
+
+// Scan decodes the current row into dest and runs its AfterFind hook
+func (r *Rows) Scan(dest interface{}) error {
+	if r.current == nil {
+		return fmt.Errorf("Scan called without a successful Next")
+	}
+	if err := json.Unmarshal(r.current, dest); err != nil {
+		return err
+	}
+	return runHook(r.ctx, dest, AfterFinder.AfterFind)
+}
+
+// Err returns the error that stopped the iteration, if any
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// Close closes the response body
+// Closing before the last row drops the connection instead of reusing it
+func (r *Rows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.body.Close()
+}
+
+// Stream executes the query and calls fn for each row, decoded into T
+// Iteration stops at the first error returned by fn, which is returned
+func Stream[T any](ctx context.Context, q *QueryBuilder, fn func(row T) error) error {
+	rows, err := q.Rows(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row T
+		if err := rows.Scan(&row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,108 @@
+package supabaseorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// streamRows returns a JSON array of n rows with increasing ids
+func streamRows(n int) string {
+	var rows strings.Builder
+	rows.WriteString("[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			rows.WriteString(",")
+		}
+		fmt.Fprintf(&rows, `{"id":%d}`, i)
+	}
+	rows.WriteString("]")
+	return rows.String()
+}
+
+func TestStream(t *testing.T) {
+	client := newCaptureServer(t, respond(http.StatusOK, streamRows(1000))).client()
+
+	type row struct {
+		ID int `json:"id"`
+	}
+
+	count, sum := 0, 0
+	err := Stream(context.Background(), client.Table("events"), func(r row) error {
+		count++
+		sum += r.ID
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if count != 1000 || sum != 499500 {
+		t.Errorf("Expected 1000 rows summing to 499500, got %d rows summing to %d", count, sum)
+	}
+}
+
+func TestStreamStopsOnCallbackError(t *testing.T) {
+	client := newCaptureServer(t, respond(http.StatusOK, streamRows(1000))).client()
+
+	stop := errors.New("stop")
+	count := 0
+	err := Stream(context.Background(), client.Table("events"), func(r map[string]interface{}) error {
+		count++
+		if count == 3 {
+			return stop
+		}
+		return nil
+	})
+
+	if !errors.Is(err, stop) {
+		t.Errorf("Expected callback error, got %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected iteration to stop after 3 rows, got %d", count)
+	}
+}
+
+func TestRows(t *testing.T) {
+	client := newCaptureServer(t, respond(http.StatusOK, streamRows(2))).client()
+
+	rows, err := client.Table("events").Rows(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var r struct {
+			ID int `json:"id"`
+		}
+		if err := rows.Scan(&r); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		ids = append(ids, r.ID)
+	}
+
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fmt.Sprint(ids) != "[0 1]" {
+		t.Errorf("Expected ids [0 1], got %v", ids)
+	}
+	if !rows.closed {
+		t.Error("Expected body to be closed after the last row")
+	}
+}
+
+func TestRowsAPIError(t *testing.T) {
+	client := newCaptureServer(t, respond(http.StatusBadRequest, `{"code":"42703","message":"column does not exist"}`)).client()
+
+	_, err := client.Table("events").Rows(context.Background())
+	var pgErr *PostgrestError
+	if !errors.As(err, &pgErr) || pgErr.Code != "42703" {
+		t.Errorf("Expected PostgrestError 42703, got %v", err)
+	}
+}
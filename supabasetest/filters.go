@@ -0,0 +1,391 @@
+package supabasetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// reservedParams are the query parameters that are not filters
+var reservedParams = map[string]bool{
+	"select":      true,
+	"order":       true,
+	"limit":       true,
+	"offset":      true,
+	"on_conflict": true,
+	"columns":     true,
+}
+
+// condition matches rows
+type condition interface {
+	match(row map[string]interface{}) bool
+}
+
+// comparison is a filter such as age=gt.18
+type comparison struct {
+	column   string
+	operator string
+	value    string
+	negate   bool
+	pattern  *regexp.Regexp
+}
+
+// group is a logic tree such as or=(a.eq.1,b.eq.2)
+type group struct {
+	or         bool
+	negate     bool
+	conditions []condition
+}
+
+func (g *group) match(row map[string]interface{}) bool {
+	result := !g.or
+	for _, c := range g.conditions {
+		if c.match(row) == g.or {
+			result = g.or
+			break
+		}
+	}
+	return result != g.negate
+}
+
+// parseFilters parses the filters of a request's query parameters
+func parseFilters(query url.Values) (*group, error) {
+	filters := &group{}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if reservedParams[key] {
+			continue
+		}
+		for _, value := range query[key] {
+			c, err := parseParam(key, value)
+			if err != nil {
+				return nil, err
+			}
+			filters.conditions = append(filters.conditions, c)
+		}
+	}
+	return filters, nil
+}
+
+// parseParam parses a filter parameter: a column filter or a logic tree
+func parseParam(key, value string) (condition, error) {
+	switch key {
+	case "or", "and", "not.or", "not.and":
+		negate := strings.HasPrefix(key, "not.")
+		return parseGroup(strings.TrimPrefix(key, "not."), value, negate)
+	}
+
+	if strings.Contains(key, ".") {
+		return nil, badRequest("filters on embedded resources are not supported: %s", key)
+	}
+	return parseComparison(key, value, false)
+}
+
+// parseGroup parses the (a.eq.1,b.eq.2) part of a logic tree
+func parseGroup(op, value string, negate bool) (condition, error) {
+	if !strings.HasPrefix(value, "(") || !strings.HasSuffix(value, ")") {
+		return nil, badRequest("logic tree %s must be in parentheses: %s", op, value)
+	}
+
+	g := &group{or: op == "or", negate: negate}
+	for _, part := range splitTopLevel(value[1 : len(value)-1]) {
+		c, err := parseTreeCondition(part)
+		if err != nil {
+			return nil, err
+		}
+		g.conditions = append(g.conditions, c)
+	}
+	return g, nil
+}
+
+// parseTreeCondition parses a condition inside a logic tree, e.g.
+// age.gt.18, name.not.eq.Jane or and(a.eq.1,b.eq.2)
+func parseTreeCondition(s string) (condition, error) {
+	negate := false
+	rest := s
+	if strings.HasPrefix(rest, "not.") {
+		negate = true
+		rest = strings.TrimPrefix(rest, "not.")
+	}
+	for _, op := range []string{"or", "and"} {
+		if strings.HasPrefix(rest, op+"(") {
+			return parseGroup(op, rest[len(op):], negate)
+		}
+	}
+
+	column, value, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, badRequest("invalid condition %q", s)
+	}
+	return parseComparison(column, value, true)
+}
+
+// parseComparison parses the operator and value of a column filter
+func parseComparison(column, value string, inTree bool) (condition, error) {
+	c := &comparison{column: column}
+	if strings.HasPrefix(value, "not.") {
+		c.negate = true
+		value = strings.TrimPrefix(value, "not.")
+	}
+
+	operator, operand, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, badRequest("invalid filter %s=%s", column, value)
+	}
+	c.operator = operator
+	c.value = operand
+	if inTree && operator != "in" {
+		c.value = unquote(operand)
+	}
+
+	switch operator {
+	case "eq", "neq", "gt", "gte", "lt", "lte", "in", "is":
+	case "like", "ilike":
+		pattern := regexp.QuoteMeta(strings.ReplaceAll(c.value, "*", "%"))
+		pattern = strings.NewReplacer("%", ".*", "_", ".").Replace(pattern)
+		if operator == "ilike" {
+			pattern = "(?i)" + pattern
+		}
+		c.pattern = regexp.MustCompile("^" + pattern + "$")
+	default:
+		return nil, badRequest("operator %s is not supported by the fake", operator)
+	}
+	return c, nil
+}
+
+func (c *comparison) match(row map[string]interface{}) bool {
+	value := row[c.column]
+
+	if c.operator == "is" {
+		var matched bool
+		switch c.value {
+		case "null":
+			matched = value == nil
+		case "true":
+			matched = value == true
+		case "false":
+			matched = value == false
+		}
+		return matched != c.negate
+	}
+
+	// Comparisons with NULL are neither true nor false
+	if value == nil {
+		return false
+	}
+
+	var matched bool
+	switch c.operator {
+	case "eq":
+		matched = compareFilter(value, c.value) == 0
+	case "neq":
+		matched = compareFilter(value, c.value) != 0
+	case "gt":
+		matched = compareFilter(value, c.value) > 0
+	case "gte":
+		cmp := compareFilter(value, c.value)
+		matched = cmp >= 0 && cmp != incomparable
+	case "lt":
+		cmp := compareFilter(value, c.value)
+		matched = cmp < 0 && cmp != incomparable
+	case "lte":
+		cmp := compareFilter(value, c.value)
+		matched = cmp <= 0 && cmp != incomparable
+	case "like", "ilike":
+		matched = c.pattern.MatchString(fmt.Sprint(value))
+	case "in":
+		items := strings.TrimSuffix(strings.TrimPrefix(c.value, "("), ")")
+		for _, item := range splitTopLevel(items) {
+			if compareFilter(value, unquote(item)) == 0 {
+				matched = true
+				break
+			}
+		}
+	}
+	return matched != c.negate
+}
+
+// incomparable is returned by compareFilter for values of different types
+const incomparable = -2
+
+// compareFilter compares a column value with a filter operand, converting
+// the operand to the type of the value
+func compareFilter(value interface{}, operand string) int {
+	switch v := value.(type) {
+	case float64:
+		f, err := strconv.ParseFloat(operand, 64)
+		if err != nil {
+			return incomparable
+		}
+		return compareFloats(v, f)
+	case bool:
+		b, err := strconv.ParseBool(operand)
+		if err != nil {
+			return incomparable
+		}
+		return compareValues(v, b)
+	case string:
+		return strings.Compare(v, operand)
+	default:
+		data, _ := json.Marshal(v)
+		return strings.Compare(string(data), operand)
+	}
+}
+
+// compareValues orders two column values of the same type
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			return compareFloats(a, b)
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b)
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			switch {
+			case a == b:
+				return 0
+			case !a:
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// splitTopLevel splits s on the commas outside parentheses and quotes
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth := 0
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case '(':
+			if !quoted {
+				depth++
+			}
+		case ')':
+			if !quoted {
+				depth--
+			}
+		case ',':
+			if !quoted && depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(s) {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+// unquote removes the double quotes around a value of a logic tree
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(s[1 : len(s)-1])
+}
+
+// ordering is an order=column.desc.nullsfirst term
+type ordering struct {
+	column     string
+	desc       bool
+	nullsFirst bool
+}
+
+// parseOrder parses the order parameter
+func parseOrder(value string) ([]ordering, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var orders []ordering
+	for _, term := range strings.Split(value, ",") {
+		parts := strings.Split(term, ".")
+		o := ordering{column: parts[0]}
+		nullsSet := false
+		for _, modifier := range parts[1:] {
+			switch modifier {
+			case "asc":
+				o.desc = false
+			case "desc":
+				o.desc = true
+			case "nullsfirst":
+				o.nullsFirst, nullsSet = true, true
+			case "nullslast":
+				o.nullsFirst, nullsSet = false, true
+			default:
+				return nil, badRequest("invalid order %q", term)
+			}
+		}
+		// NULLs sort as the largest values by default
+		if !nullsSet {
+			o.nullsFirst = o.desc
+		}
+		orders = append(orders, o)
+	}
+	return orders, nil
+}
+
+// sortRows sorts rows in place
+func sortRows(rows []map[string]interface{}, orders []ordering) {
+	if len(orders) == 0 {
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, o := range orders {
+			a, b := rows[i][o.column], rows[j][o.column]
+			switch {
+			case a == nil && b == nil:
+				continue
+			case a == nil:
+				return o.nullsFirst
+			case b == nil:
+				return !o.nullsFirst
+			}
+			cmp := compareValues(a, b)
+			if cmp == 0 {
+				continue
+			}
+			if o.desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+}
@@ -0,0 +1,299 @@
+package supabasetest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwtSecret signs the access tokens of the fake
+const jwtSecret = "supabasetest"
+
+// tokenLifetime is the expires_in of the fake's sessions, in seconds
+const tokenLifetime = 3600
+
+// gotrue is a fake of the auth API. Sign ups are confirmed immediately
+type gotrue struct {
+	mu            sync.Mutex
+	users         map[string]*authUser
+	accessTokens  map[string]*authUser
+	refreshTokens map[string]*authUser
+	otps          map[string]string
+	nextID        int
+	nextSession   int
+}
+
+// authUser is a user of the fake
+type authUser struct {
+	id           string
+	email        string
+	phone        string
+	password     string
+	metadata     map[string]interface{}
+	createdAt    time.Time
+	updatedAt    time.Time
+	lastSignInAt time.Time
+}
+
+func newGoTrue() *gotrue {
+	return &gotrue{
+		users:         make(map[string]*authUser),
+		accessTokens:  make(map[string]*authUser),
+		refreshTokens: make(map[string]*authUser),
+		otps:          make(map[string]string),
+	}
+}
+
+// AddUser creates a confirmed user of the auth API and returns its id
+func (s *Server) AddUser(email, password string) string {
+	s.auth.mu.Lock()
+	defer s.auth.mu.Unlock()
+	return s.auth.addUser(email, password, nil).id
+}
+
+// OTP returns the last one-time password sent to email by SignInWithOTP or
+// ResetPassword, to be passed to Verify
+func (s *Server) OTP(email string) string {
+	s.auth.mu.Lock()
+	defer s.auth.mu.Unlock()
+	return s.auth.otps[email]
+}
+
+// authError is an error response of the auth API
+type authError struct {
+	Code      int    `json:"code"`
+	ErrorCode string `json:"error_code"`
+	Message   string `json:"msg"`
+}
+
+func writeAuthError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, authError{Code: status, ErrorCode: code, Message: message})
+}
+
+// serveHTTP handles the auth endpoints used by supabaseorm.Auth
+func (g *gotrue) serveHTTP(w http.ResponseWriter, r *http.Request, endpoint string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var body struct {
+		Email        string                 `json:"email"`
+		Password     string                 `json:"password"`
+		Phone        string                 `json:"phone"`
+		Data         map[string]interface{} `json:"data"`
+		CreateUser   bool                   `json:"create_user"`
+		Token        string                 `json:"token"`
+		RefreshToken string                 `json:"refresh_token"`
+	}
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && endpoint != "logout" {
+			writeAuthError(w, http.StatusBadRequest, "bad_json", "Could not parse request body as JSON")
+			return
+		}
+	}
+
+	switch {
+	case endpoint == "signup" && r.Method == http.MethodPost:
+		if _, ok := g.users[body.Email]; ok {
+			writeAuthError(w, http.StatusUnprocessableEntity, "user_already_exists", "User already registered")
+			return
+		}
+		user := g.addUser(body.Email, body.Password, body.Data)
+		user.phone = body.Phone
+		writeJSON(w, http.StatusOK, g.newSession(user))
+
+	case endpoint == "token" && r.Method == http.MethodPost:
+		switch r.URL.Query().Get("grant_type") {
+		case "password":
+			user, ok := g.users[body.Email]
+			if !ok || user.password == "" || user.password != body.Password {
+				writeAuthError(w, http.StatusBadRequest, "invalid_credentials", "Invalid login credentials")
+				return
+			}
+			writeJSON(w, http.StatusOK, g.newSession(user))
+		case "refresh_token":
+			user, ok := g.refreshTokens[body.RefreshToken]
+			if !ok {
+				writeAuthError(w, http.StatusBadRequest, "refresh_token_not_found", "Invalid Refresh Token: Refresh Token Not Found")
+				return
+			}
+			// Refresh tokens are used once
+			delete(g.refreshTokens, body.RefreshToken)
+			writeJSON(w, http.StatusOK, g.newSession(user))
+		default:
+			writeAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "unsupported grant type")
+		}
+
+	case endpoint == "otp" && r.Method == http.MethodPost:
+		if _, ok := g.users[body.Email]; !ok {
+			if !body.CreateUser {
+				writeAuthError(w, http.StatusUnprocessableEntity, "otp_disabled", "Signups not allowed for otp")
+				return
+			}
+			g.addUser(body.Email, "", nil)
+		}
+		g.otps[body.Email] = newOTP()
+		writeJSON(w, http.StatusOK, struct{}{})
+
+	case endpoint == "recover" && r.Method == http.MethodPost:
+		// Unknown emails are not disclosed
+		if _, ok := g.users[body.Email]; ok {
+			g.otps[body.Email] = newOTP()
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+
+	case endpoint == "verify" && r.Method == http.MethodPost:
+		user, ok := g.users[body.Email]
+		if !ok || body.Token == "" || g.otps[body.Email] != body.Token {
+			writeAuthError(w, http.StatusForbidden, "otp_expired", "Token has expired or is invalid")
+			return
+		}
+		delete(g.otps, body.Email)
+		writeJSON(w, http.StatusOK, g.newSession(user))
+
+	case endpoint == "user":
+		user, ok := g.accessTokens[bearerToken(r)]
+		if !ok {
+			writeAuthError(w, http.StatusUnauthorized, "bad_jwt", "invalid JWT: unable to parse or verify signature")
+			return
+		}
+		if r.Method == http.MethodPut {
+			if body.Password != "" {
+				user.password = body.Password
+			}
+			if body.Data != nil {
+				user.metadata = body.Data
+			}
+			user.updatedAt = time.Now().UTC()
+		}
+		writeJSON(w, http.StatusOK, user.json())
+
+	case endpoint == "logout" && r.Method == http.MethodPost:
+		user, ok := g.accessTokens[bearerToken(r)]
+		if !ok {
+			writeAuthError(w, http.StatusUnauthorized, "bad_jwt", "invalid JWT: unable to parse or verify signature")
+			return
+		}
+		for token, owner := range g.accessTokens {
+			if owner == user {
+				delete(g.accessTokens, token)
+			}
+		}
+		for token, owner := range g.refreshTokens {
+			if owner == user {
+				delete(g.refreshTokens, token)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeAuthError(w, http.StatusNotFound, "not_found", "unsupported auth endpoint "+r.Method+" "+endpoint)
+	}
+}
+
+// addUser creates a user; g.mu must be held
+func (g *gotrue) addUser(email, password string, metadata map[string]interface{}) *authUser {
+	g.nextID++
+	now := time.Now().UTC()
+	user := &authUser{
+		id:        fmt.Sprintf("00000000-0000-4000-8000-%012d", g.nextID),
+		email:     email,
+		password:  password,
+		metadata:  metadata,
+		createdAt: now,
+		updatedAt: now,
+	}
+	g.users[email] = user
+	return user
+}
+
+// newSession signs user in; g.mu must be held
+func (g *gotrue) newSession(user *authUser) map[string]interface{} {
+	g.nextSession++
+	now := time.Now().UTC()
+	user.lastSignInAt = now
+
+	access := signJWT(map[string]interface{}{
+		"sub":        user.id,
+		"email":      user.email,
+		"role":       "authenticated",
+		"aud":        "authenticated",
+		"session_id": g.nextSession,
+		"iat":        now.Unix(),
+		"exp":        now.Unix() + tokenLifetime,
+	})
+	refresh := randomToken()
+	g.accessTokens[access] = user
+	g.refreshTokens[refresh] = user
+
+	return map[string]interface{}{
+		"access_token":  access,
+		"token_type":    "bearer",
+		"expires_in":    tokenLifetime,
+		"expires_at":    now.Unix() + tokenLifetime,
+		"refresh_token": refresh,
+		"user":          user.json(),
+	}
+}
+
+// json returns the user as sent by the auth API
+func (u *authUser) json() map[string]interface{} {
+	metadata := u.metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	user := map[string]interface{}{
+		"id":                 u.id,
+		"aud":                "authenticated",
+		"role":               "authenticated",
+		"email":              u.email,
+		"phone":              u.phone,
+		"email_confirmed_at": u.createdAt,
+		"confirmed_at":       u.createdAt,
+		"app_metadata":       map[string]interface{}{"provider": "email", "providers": []string{"email"}},
+		"user_metadata":      metadata,
+		"created_at":         u.createdAt,
+		"updated_at":         u.updatedAt,
+	}
+	if !u.lastSignInAt.IsZero() {
+		user["last_sign_in_at"] = u.lastSignInAt
+	}
+	return user
+}
+
+// bearerToken returns the token of the Authorization header
+func bearerToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// signJWT returns an HS256 JWT with the given claims
+func signJWT(claims map[string]interface{}) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, _ := json.Marshal(claims)
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, []byte(jwtSecret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// randomToken returns a random refresh token
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newOTP returns a random six digit one-time password
+func newOTP() string {
+	n, _ := rand.Int(rand.Reader, big.NewInt(1000000))
+	return fmt.Sprintf("%06d", n.Int64())
+}
@@ -0,0 +1,87 @@
+package supabasetest
+
+import (
+	"context"
+	"testing"
+
+	supabaseorm "github.com/zoc/supabase-orm"
+)
+
+func TestAuth(t *testing.T) {
+	fake := New(t)
+	fake.AddUser("jane@example.com", "hunter2")
+	auth := supabaseorm.New(fake.URL, "test-key").Auth()
+	ctx := context.Background()
+
+	if _, err := auth.SignInWithPassword(ctx, supabaseorm.SignInRequest{Email: "jane@example.com", Password: "wrong"}); err == nil {
+		t.Error("Expected invalid credentials to fail")
+	}
+
+	session, err := auth.SignInWithPassword(ctx, supabaseorm.SignInRequest{Email: "jane@example.com", Password: "hunter2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if session.AccessToken == "" || session.RefreshToken == "" || session.User.Email != "jane@example.com" {
+		t.Errorf("Unexpected session %+v", session)
+	}
+
+	user, err := auth.GetUser(ctx, session.AccessToken)
+	if err != nil || user.ID != session.User.ID || user.Role != "authenticated" {
+		t.Errorf("Unexpected user %+v, %v", user, err)
+	}
+
+	refreshed, err := auth.RefreshToken(ctx, supabaseorm.RefreshTokenRequest{RefreshToken: session.RefreshToken})
+	if err != nil || refreshed.AccessToken == session.AccessToken {
+		t.Errorf("Expected a new session, got %+v, %v", refreshed, err)
+	}
+	if _, err := auth.RefreshToken(ctx, supabaseorm.RefreshTokenRequest{RefreshToken: session.RefreshToken}); err == nil {
+		t.Error("Expected a used refresh token to fail")
+	}
+
+	if err := auth.UpdatePassword(ctx, supabaseorm.UpdatePasswordRequest{Password: "correct horse"}, refreshed.AccessToken); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := auth.SignOut(ctx, refreshed.AccessToken); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := auth.GetUser(ctx, refreshed.AccessToken); err == nil {
+		t.Error("Expected the signed out token to be rejected")
+	}
+	if _, err := auth.SignInWithPassword(ctx, supabaseorm.SignInRequest{Email: "jane@example.com", Password: "correct horse"}); err != nil {
+		t.Errorf("Expected the new password to work, got %v", err)
+	}
+}
+
+func TestAuthSignUpAndOTP(t *testing.T) {
+	fake := New(t)
+	auth := supabaseorm.New(fake.URL, "test-key").Auth()
+	ctx := context.Background()
+
+	signUp := supabaseorm.SignUpRequest{Email: "john@example.com", Password: "secret", UserMetadata: map[string]interface{}{"name": "John"}}
+	session, err := auth.SignUp(ctx, signUp)
+	if err != nil || session.User.UserMetadata["name"] != "John" {
+		t.Fatalf("Unexpected sign up %+v, %v", session, err)
+	}
+	if _, err := auth.SignUp(ctx, signUp); err == nil {
+		t.Error("Expected a second sign up to fail")
+	}
+
+	if err := auth.SignInWithOTP(ctx, supabaseorm.SignInRequest{Email: "new@example.com"}); err == nil {
+		t.Error("Expected an OTP for an unknown user to fail")
+	}
+	if err := auth.SignInWithOTP(ctx, supabaseorm.SignInRequest{Email: "new@example.com", CreateUser: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	code := fake.OTP("new@example.com")
+	if len(code) != 6 {
+		t.Fatalf("Expected a six digit code, got %q", code)
+	}
+	verified, err := auth.Verify(ctx, supabaseorm.VerifyRequest{Email: "new@example.com", Token: code, Type: "email"})
+	if err != nil || verified.User.Email != "new@example.com" {
+		t.Errorf("Unexpected verification %+v, %v", verified, err)
+	}
+	if _, err := auth.Verify(ctx, supabaseorm.VerifyRequest{Email: "new@example.com", Token: code, Type: "email"}); err == nil {
+		t.Error("Expected a used code to fail")
+	}
+}
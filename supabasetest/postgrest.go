@@ -0,0 +1,521 @@
+package supabasetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// mediaTypeObject asks for a single row as an object
+const mediaTypeObject = "application/vnd.pgrst.object+json"
+
+// table is an in-memory table
+type table struct {
+	name       string
+	primaryKey []string
+	rows       []map[string]interface{}
+	nextID     float64
+}
+
+// foreignKey lets tables be embedded in each other
+type foreignKey struct {
+	table, column               string
+	foreignTable, foreignColumn string
+}
+
+// CreateTable creates an empty table with the given primary key columns,
+// "id" by default. Rows inserted without an id get the next integer
+func (s *Server) CreateTable(name string, primaryKey ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(primaryKey) == 0 {
+		primaryKey = []string{"id"}
+	}
+	s.tables[name] = &table{name: name, primaryKey: primaryKey, nextID: 1}
+}
+
+// ForeignKey declares that table.column references
+// foreignTable.foreignColumn, so that each table can be embedded in the
+// other's selects
+func (s *Server) ForeignKey(table, column, foreignTable, foreignColumn string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.foreignKeys = append(s.foreignKeys, foreignKey{
+		table: table, column: column,
+		foreignTable: foreignTable, foreignColumn: foreignColumn,
+	})
+}
+
+// Seed inserts rows into a table, creating it if needed. Rows are maps or
+// structs encoded as JSON. Seed panics if a row cannot be inserted
+func (s *Server) Seed(name string, rows ...interface{}) {
+	s.mu.Lock()
+	if _, ok := s.tables[name]; !ok {
+		s.tables[name] = &table{name: name, primaryKey: []string{"id"}, nextID: 1}
+	}
+	s.mu.Unlock()
+
+	data, err := json.Marshal(rows)
+	if err != nil {
+		panic(fmt.Sprintf("supabasetest: encoding rows of %s: %v", name, err))
+	}
+	decoded, err := decodeRows(data)
+	if err != nil {
+		panic(fmt.Sprintf("supabasetest: rows of %s: %v", name, err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, apply, err := s.insert(s.tables[name], decoded, "", nil)
+	if err != nil {
+		panic(fmt.Sprintf("supabasetest: seeding %s: %v", name, err))
+	}
+	apply()
+}
+
+// Rows returns a copy of the rows of a table, in insertion order
+func (s *Server) Rows(name string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tables[name]
+	if !ok {
+		return nil
+	}
+	rows := make([]map[string]interface{}, len(t.rows))
+	for i, row := range t.rows {
+		rows[i] = copyRow(row)
+	}
+	return rows
+}
+
+// serveTable handles the requests to a table
+func (s *Server) serveTable(w http.ResponseWriter, r *http.Request, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tables[name]
+	if !ok {
+		writeError(w, &Error{
+			Status:  http.StatusNotFound,
+			Code:    "42P01",
+			Message: fmt.Sprintf("relation \"public.%s\" does not exist", name),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	prefer := parsePrefer(r.Header)
+
+	var err error
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		err = s.serveSelect(w, r, t, query, prefer)
+	case http.MethodPost:
+		err = s.serveInsert(w, r, t, query, prefer)
+	case http.MethodPatch:
+		err = s.serveUpdate(w, r, t, query, prefer)
+	case http.MethodDelete:
+		err = s.serveDelete(w, r, t, query, prefer)
+	default:
+		err = &Error{Status: http.StatusMethodNotAllowed, Code: "PGRST117", Message: "unsupported HTTP method " + r.Method}
+	}
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+// serveSelect handles reads
+func (s *Server) serveSelect(w http.ResponseWriter, r *http.Request, t *table, query url.Values, prefer map[string]string) error {
+	accept := r.Header.Get("Accept")
+	switch {
+	case accept == "", accept == "*/*", strings.HasPrefix(accept, "application/json"), strings.HasPrefix(accept, mediaTypeObject):
+	default:
+		return &Error{Status: http.StatusNotAcceptable, Code: "PGRST107", Message: "media type " + accept + " is not supported by the fake"}
+	}
+
+	rows, err := s.matchingRows(t, query)
+	if err != nil {
+		return err
+	}
+
+	orders, err := parseOrder(query.Get("order"))
+	if err != nil {
+		return err
+	}
+	sortRows(rows, orders)
+
+	columns, err := parseSelect(query.Get("select"))
+	if err != nil {
+		return err
+	}
+	rows, err = s.project(t.name, rows, columns)
+	if err != nil {
+		return err
+	}
+	total := len(rows)
+
+	offset, limit, err := pagination(r, query)
+	if err != nil {
+		return err
+	}
+	if offset > len(rows) {
+		offset = len(rows)
+	}
+	rows = rows[offset:]
+	if limit >= 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+
+	count := "*"
+	if prefer["count"] != "" {
+		count = strconv.Itoa(total)
+	}
+	if len(rows) == 0 {
+		w.Header().Set("Content-Range", "*/"+count)
+	} else {
+		w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/%s", offset, offset+len(rows)-1, count))
+	}
+
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+	return writeRows(w, r, http.StatusOK, rows)
+}
+
+// pagination returns the offset and limit of a read, from the limit and
+// offset parameters or the Range header. The limit is -1 if there is none
+func pagination(r *http.Request, query url.Values) (offset, limit int, err error) {
+	limit = -1
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			return 0, 0, badRequest("invalid limit %q", value)
+		}
+	}
+	if value := query.Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil {
+			return 0, 0, badRequest("invalid offset %q", value)
+		}
+	}
+
+	if value := r.Header.Get("Range"); value != "" {
+		start, end, ok := strings.Cut(value, "-")
+		from, err := strconv.Atoi(start)
+		if !ok || err != nil {
+			return 0, 0, badRequest("invalid range %q", value)
+		}
+		offset = from
+		if to, err := strconv.Atoi(end); err == nil {
+			limit = to - from + 1
+		}
+	}
+	return offset, limit, nil
+}
+
+// serveInsert handles inserts and upserts
+func (s *Server) serveInsert(w http.ResponseWriter, r *http.Request, t *table, query url.Values, prefer map[string]string) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	rows, err := decodeRows(body)
+	if err != nil {
+		return err
+	}
+
+	var onConflict []string
+	if value := query.Get("on_conflict"); value != "" {
+		onConflict = strings.Split(value, ",")
+	}
+
+	written, apply, err := s.insert(t, rows, prefer["resolution"], onConflict)
+	if err != nil {
+		return err
+	}
+	return s.writeRepresentation(w, r, t, query, prefer, http.StatusCreated, written, apply)
+}
+
+// insert stages the insertion of rows into t, written to it by apply.
+// resolution is merge-duplicates or ignore-duplicates for upserts, matching
+// rows on onConflict or the primary key. Nothing is staged if a row fails
+func (s *Server) insert(t *table, rows []map[string]interface{}, resolution string, onConflict []string) ([]map[string]interface{}, func(), error) {
+	if len(onConflict) == 0 {
+		onConflict = t.primaryKey
+	}
+
+	updated := make([]map[string]interface{}, len(t.rows))
+	for i, row := range t.rows {
+		updated[i] = copyRow(row)
+	}
+	nextID := t.nextID
+
+	var written []map[string]interface{}
+	for _, row := range rows {
+		row = copyRow(row)
+		if len(t.primaryKey) == 1 && t.primaryKey[0] == "id" && row["id"] == nil {
+			row["id"] = nextID
+			nextID++
+		}
+		if id, ok := row["id"].(float64); ok && id >= nextID {
+			nextID = id + 1
+		}
+
+		if existing := findRow(updated, row, onConflict); existing != nil {
+			switch resolution {
+			case "merge-duplicates":
+				for column, value := range row {
+					existing[column] = value
+				}
+				written = append(written, existing)
+				continue
+			case "ignore-duplicates":
+				continue
+			}
+			return nil, nil, &Error{
+				Status:  http.StatusConflict,
+				Code:    "23505",
+				Message: "duplicate key value violates unique constraint",
+				Details: fmt.Sprintf("Key (%s) already exists.", strings.Join(onConflict, ", ")),
+			}
+		}
+
+		updated = append(updated, row)
+		written = append(written, row)
+	}
+
+	apply := func() {
+		t.rows = updated
+		t.nextID = nextID
+	}
+	return written, apply, nil
+}
+
+// findRow returns the row of rows with the same values as row in columns
+func findRow(rows []map[string]interface{}, row map[string]interface{}, columns []string) map[string]interface{} {
+	for _, column := range columns {
+		if row[column] == nil {
+			return nil
+		}
+	}
+
+	for _, candidate := range rows {
+		same := true
+		for _, column := range columns {
+			if candidate[column] == nil || compareValues(candidate[column], row[column]) != 0 {
+				same = false
+				break
+			}
+		}
+		if same {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// serveUpdate handles updates
+func (s *Server) serveUpdate(w http.ResponseWriter, r *http.Request, t *table, query url.Values, prefer map[string]string) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(body, &values); err != nil {
+		return badRequest("updates must be a JSON object: %v", err)
+	}
+
+	matched, err := s.writeTargets(t, query, prefer)
+	if err != nil {
+		return err
+	}
+
+	// Rows are changed once the response is known not to fail
+	updated := make([]map[string]interface{}, len(t.rows))
+	var written []map[string]interface{}
+	for i, row := range t.rows {
+		updated[i] = row
+		if matched[i] {
+			updated[i] = copyRow(row)
+			for column, value := range values {
+				updated[i][column] = value
+			}
+			written = append(written, updated[i])
+		}
+	}
+	return s.writeRepresentation(w, r, t, query, prefer, http.StatusOK, written, func() {
+		t.rows = updated
+	})
+}
+
+// serveDelete handles deletes
+func (s *Server) serveDelete(w http.ResponseWriter, r *http.Request, t *table, query url.Values, prefer map[string]string) error {
+	matched, err := s.writeTargets(t, query, prefer)
+	if err != nil {
+		return err
+	}
+
+	var kept, written []map[string]interface{}
+	for i, row := range t.rows {
+		if matched[i] {
+			written = append(written, row)
+		} else {
+			kept = append(kept, row)
+		}
+	}
+	return s.writeRepresentation(w, r, t, query, prefer, http.StatusOK, written, func() {
+		t.rows = kept
+	})
+}
+
+// writeTargets returns which rows of t an update or delete matches,
+// enforcing the max-affected preference
+func (s *Server) writeTargets(t *table, query url.Values, prefer map[string]string) ([]bool, error) {
+	filters, err := parseFilters(query)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]bool, len(t.rows))
+	count := 0
+	for i, row := range t.rows {
+		if filters.match(row) {
+			matched[i] = true
+			count++
+		}
+	}
+
+	if max, ok := prefer["max-affected"]; ok && prefer["handling"] == "strict" {
+		if limit, err := strconv.Atoi(max); err == nil && count > limit {
+			return nil, &Error{
+				Status:  http.StatusBadRequest,
+				Code:    "PGRST124",
+				Message: "Query result exceeds max-affected preference constraint",
+				Details: fmt.Sprintf("The query affects %d rows", count),
+			}
+		}
+	}
+	return matched, nil
+}
+
+// writeRepresentation answers a write with the written rows if asked for
+// with return=representation, or with no content. apply writes the staged
+// rows to the table, unless the response fails
+func (s *Server) writeRepresentation(w http.ResponseWriter, r *http.Request, t *table, query url.Values, prefer map[string]string, status int, written []map[string]interface{}, apply func()) error {
+	if prefer["return"] != "representation" {
+		apply()
+		if status == http.StatusOK {
+			status = http.StatusNoContent
+		}
+		w.WriteHeader(status)
+		return nil
+	}
+
+	columns, err := parseSelect(query.Get("select"))
+	if err != nil {
+		return err
+	}
+	rows, err := s.project(t.name, written, columns)
+	if err != nil {
+		return err
+	}
+	if err := checkSingle(r, rows); err != nil {
+		return err
+	}
+	apply()
+	return writeRows(w, r, status, rows)
+}
+
+// checkSingle fails with PGRST116 if the client asked for a single row and
+// rows has none or several
+func checkSingle(r *http.Request, rows []map[string]interface{}) error {
+	if strings.HasPrefix(r.Header.Get("Accept"), mediaTypeObject) && len(rows) != 1 {
+		return &Error{
+			Status:  http.StatusNotAcceptable,
+			Code:    "PGRST116",
+			Message: "JSON object requested, multiple (or no) rows returned",
+			Details: fmt.Sprintf("The result contains %d rows", len(rows)),
+		}
+	}
+	return nil
+}
+
+// writeRows writes rows as a JSON array, or as an object if the client
+// asked for a single row
+func writeRows(w http.ResponseWriter, r *http.Request, status int, rows []map[string]interface{}) error {
+	if err := checkSingle(r, rows); err != nil {
+		return err
+	}
+	if strings.HasPrefix(r.Header.Get("Accept"), mediaTypeObject) {
+		writeJSON(w, status, rows[0])
+		return nil
+	}
+
+	if rows == nil {
+		rows = []map[string]interface{}{}
+	}
+	writeJSON(w, status, rows)
+	return nil
+}
+
+// matchingRows returns copies of the rows of t matching the query's filters
+func (s *Server) matchingRows(t *table, query url.Values) ([]map[string]interface{}, error) {
+	filters, err := parseFilters(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	for _, row := range t.rows {
+		if filters.match(row) {
+			rows = append(rows, copyRow(row))
+		}
+	}
+	return rows, nil
+}
+
+// parsePrefer parses the Prefer headers into their preferences
+func parsePrefer(header http.Header) map[string]string {
+	prefer := make(map[string]string)
+	for _, value := range header.Values("Prefer") {
+		for _, preference := range strings.Split(value, ",") {
+			key, val, _ := strings.Cut(strings.TrimSpace(preference), "=")
+			prefer[key] = val
+		}
+	}
+	return prefer
+}
+
+// decodeRows decodes a JSON object or array of objects
+func decodeRows(body []byte) ([]map[string]interface{}, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '{' {
+		var row map[string]interface{}
+		if err := json.Unmarshal(body, &row); err != nil {
+			return nil, badRequest("invalid JSON: %v", err)
+		}
+		return []map[string]interface{}{row}, nil
+	}
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, badRequest("rows must be JSON objects: %v", err)
+	}
+	return rows, nil
+}
+
+// copyRow returns a shallow copy of row
+func copyRow(row map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		copied[column] = value
+	}
+	return copied
+}
@@ -0,0 +1,287 @@
+package supabasetest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	supabaseorm "github.com/zoc/supabase-orm"
+)
+
+type user struct {
+	ID     int     `json:"id,omitempty"`
+	Name   string  `json:"name"`
+	Age    int     `json:"age"`
+	Email  *string `json:"email"`
+	Active bool    `json:"active"`
+}
+
+func newUsers(t *testing.T) (*Server, *supabaseorm.Client) {
+	fake := New(t)
+	email := "jane@example.com"
+	fake.Seed("users",
+		user{Name: "Jane", Age: 34, Email: &email, Active: true},
+		user{Name: "John", Age: 17, Active: true},
+		user{Name: "Jim, Jr.", Age: 52},
+	)
+	return fake, supabaseorm.New(fake.URL, "test-key")
+}
+
+func TestSelect(t *testing.T) {
+	_, client := newUsers(t)
+
+	var users []user
+	if err := client.Table("users").Where("age", "gte", 18).Order("age", "desc").Get(&users); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(users) != 2 || users[0].Name != "Jim, Jr." || users[1].Name != "Jane" {
+		t.Errorf("Unexpected users %+v", users)
+	}
+
+	var names []map[string]interface{}
+	if err := client.Table("users").Select("name").Order("id", "asc").Limit(2).Offset(1).Get(&names); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(names) != 2 || names[0]["name"] != "John" || len(names[0]) != 1 {
+		t.Errorf("Unexpected rows %v", names)
+	}
+
+	var one user
+	if err := client.Table("users").Where("name", "eq", "Jane").Single().Get(&one); err != nil || one.ID != 1 {
+		t.Errorf("Unexpected single row %+v, %v", one, err)
+	}
+	err := client.Table("users").Single().Get(&one)
+	var apiErr *supabaseorm.PostgrestError
+	if !errors.As(err, &apiErr) || apiErr.Code != "PGRST116" {
+		t.Errorf("Expected PGRST116 for several rows, got %v", err)
+	}
+
+	if err := client.Table("missing").Get(&users); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a missing table error, got %v", err)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	_, client := newUsers(t)
+
+	tests := []struct {
+		name  string
+		query func(q *supabaseorm.QueryBuilder) *supabaseorm.QueryBuilder
+		want  []string
+	}{
+		{"neq", func(q *supabaseorm.QueryBuilder) *supabaseorm.QueryBuilder { return q.Where("name", "neq", "Jane") }, []string{"John", "Jim, Jr."}},
+		{"lt", func(q *supabaseorm.QueryBuilder) *supabaseorm.QueryBuilder { return q.Where("age", "<", 18) }, []string{"John"}},
+		{"like", func(q *supabaseorm.QueryBuilder) *supabaseorm.QueryBuilder { return q.Where("name", "like", "J%n%") }, []string{"Jane", "John"}},
+		{"ilike", func(q *supabaseorm.QueryBuilder) *supabaseorm.QueryBuilder { return q.Where("name", "ilike", "jim*") }, []string{"Jim, Jr."}},
+		{"in", func(q *supabaseorm.QueryBuilder) *supabaseorm.QueryBuilder {
+			return q.Where("name", "in", []string{"John", "Jim, Jr."})
+		}, []string{"John", "Jim, Jr."}},
+		{"is null", func(q *supabaseorm.QueryBuilder) *supabaseorm.QueryBuilder { return q.Where("email", "is", nil) }, []string{"John", "Jim, Jr."}},
+		{"bool", func(q *supabaseorm.QueryBuilder) *supabaseorm.QueryBuilder { return q.Where("active", "eq", false) }, []string{"Jim, Jr."}},
+		{"or", func(q *supabaseorm.QueryBuilder) *supabaseorm.QueryBuilder {
+			return q.Where("age", "lt", 18).OrWhere("name", "eq", "Jim, Jr.")
+		}, []string{"John", "Jim, Jr."}},
+		{"and", func(q *supabaseorm.QueryBuilder) *supabaseorm.QueryBuilder {
+			return q.Where("active", "eq", true).Where("age", "gt", 18)
+		}, []string{"Jane"}},
+		{"raw", func(q *supabaseorm.QueryBuilder) *supabaseorm.QueryBuilder {
+			return q.WhereRaw("or(age.gt.50,and(age.lt.18,name.not.eq.Jane))")
+		}, []string{"John", "Jim, Jr."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var users []user
+			if err := tt.query(client.Table("users")).Order("id", "asc").Get(&users); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var names []string
+			for _, u := range users {
+				names = append(names, u.Name)
+			}
+			if len(names) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, names)
+			}
+			for i := range names {
+				if names[i] != tt.want[i] {
+					t.Fatalf("Expected %v, got %v", tt.want, names)
+				}
+			}
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	_, client := newUsers(t)
+
+	count, err := client.Table("users").Where("active", "eq", true).Count()
+	if err != nil || count != 2 {
+		t.Errorf("Expected 2 rows, got %d, %v", count, err)
+	}
+
+	var users []user
+	pages := client.Table("users").Order("id", "asc").Paginate(context.Background(), supabaseorm.PageSize(2))
+	for pages.Next() {
+		var page []user
+		if err := pages.Scan(&page); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		users = append(users, page...)
+	}
+	if err := pages.Err(); err != nil || len(users) != 3 {
+		t.Errorf("Expected 3 paginated users, got %d, %v", len(users), err)
+	}
+}
+
+func TestWrites(t *testing.T) {
+	fake, client := newUsers(t)
+
+	if err := client.Table("users").Insert(&user{Name: "Joan", Age: 28}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rows := fake.Rows("users")
+	if len(rows) != 4 || rows[3]["id"] != float64(4) || rows[3]["name"] != "Joan" {
+		t.Errorf("Unexpected rows after insert %v", rows)
+	}
+
+	err := client.Table("users").Insert(map[string]interface{}{"id": 1, "name": "Duplicate"})
+	var apiErr *supabaseorm.PostgrestError
+	if !errors.As(err, &apiErr) || apiErr.Code != "23505" {
+		t.Errorf("Expected a unique violation, got %v", err)
+	}
+
+	var inserted []user
+	result, err := client.Table("users").InsertMany([]user{{Name: "A"}, {Name: "B"}}, supabaseorm.Returning(&inserted))
+	if err != nil || result.Inserted != 2 || len(inserted) != 2 || inserted[1].ID != 6 {
+		t.Errorf("Unexpected bulk insert %+v, %v, %v", result, inserted, err)
+	}
+
+	upsert := client.Table("users").Header("Prefer", "resolution=merge-duplicates")
+	if err := upsert.Insert(map[string]interface{}{"id": 2, "name": "Johnny"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rows := fake.Rows("users"); rows[1]["name"] != "Johnny" || rows[1]["age"] != float64(17) || len(rows) != 6 {
+		t.Errorf("Expected the upsert to merge row 2, got %v", rows)
+	}
+
+	var updated []user
+	err = client.Table("users").Where("age", "gt", 30).Update(map[string]interface{}{"active": false})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	client.Table("users").Where("active", "eq", false).Get(&updated)
+	if len(updated) != 5 || updated[0].Name != "Jane" {
+		t.Errorf("Expected 5 inactive users, got %+v", updated)
+	}
+
+	err = client.Table("users").Where("active", "eq", false).MaxAffected(2).Delete()
+	if !errors.Is(err, supabaseorm.ErrMaxAffected) {
+		t.Errorf("Expected ErrMaxAffected, got %v", err)
+	}
+	if err := client.Table("users").Where("id", "eq", 3).Delete(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rows := fake.Rows("users"); len(rows) != 5 {
+		t.Errorf("Expected 5 rows after the delete, got %d", len(rows))
+	}
+}
+
+func TestFailedWritesLeaveRows(t *testing.T) {
+	fake, client := newUsers(t)
+	single := func() *supabaseorm.QueryBuilder {
+		return client.Table("users").Header("Prefer", "return=representation").Single()
+	}
+
+	var apiErr *supabaseorm.PostgrestError
+	err := single().Where("age", "gt", 20).Update(map[string]interface{}{"name": "Changed"})
+	if !errors.As(err, &apiErr) || apiErr.Code != "PGRST116" {
+		t.Errorf("Expected PGRST116 for an update of several rows, got %v", err)
+	}
+	err = single().Where("age", "gt", 20).Delete()
+	if !errors.As(err, &apiErr) || apiErr.Code != "PGRST116" {
+		t.Errorf("Expected PGRST116 for a delete of several rows, got %v", err)
+	}
+	err = single().Insert([]user{{Name: "A"}, {Name: "B"}})
+	if !errors.As(err, &apiErr) || apiErr.Code != "PGRST116" {
+		t.Errorf("Expected PGRST116 for an insert of several rows, got %v", err)
+	}
+
+	rows := fake.Rows("users")
+	if len(rows) != 3 || rows[0]["name"] != "Jane" || rows[2]["name"] != "Jim, Jr." {
+		t.Errorf("Expected failed writes to leave the rows unchanged, got %v", rows)
+	}
+	var added []user
+	if err := client.Table("users").Insert(&user{Name: "Joan"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if client.Table("users").Where("name", "eq", "Joan").Get(&added); len(added) != 1 || added[0].ID != 4 {
+		t.Errorf("Expected the failed insert not to use ids, got %+v", added)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	fake := New(t)
+	fake.Seed("users", map[string]interface{}{"name": "Jane"}, map[string]interface{}{"name": "John"})
+	fake.Seed("posts",
+		map[string]interface{}{"title": "Hello", "user_id": 1},
+		map[string]interface{}{"title": "Again", "user_id": 1},
+	)
+	fake.ForeignKey("posts", "user_id", "users", "id")
+	client := supabaseorm.New(fake.URL, "test-key")
+
+	var users []struct {
+		Name  string `json:"name"`
+		Posts []struct {
+			Title string `json:"title"`
+		} `json:"posts"`
+	}
+	if err := client.Table("users").Select("name").InnerJoin("posts", "id", "user_id").Order("id", "asc").Get(&users); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(users) != 2 || len(users[0].Posts) != 2 || users[0].Posts[1].Title != "Again" || len(users[1].Posts) != 0 {
+		t.Errorf("Unexpected users %+v", users)
+	}
+
+	var posts []map[string]interface{}
+	if err := client.Table("posts").Select("title", "author:users!inner(name)").Get(&posts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	author, _ := posts[0]["author"].(map[string]interface{})
+	if len(posts) != 2 || author["name"] != "Jane" {
+		t.Errorf("Unexpected posts %v", posts)
+	}
+}
+
+func TestRPC(t *testing.T) {
+	fake := New(t)
+	fake.HandleRPC("add", func(args map[string]interface{}) (interface{}, error) {
+		a, _ := args["a"].(float64)
+		b, _ := args["b"].(float64)
+		return a + b, nil
+	})
+	fake.HandleRPC("execute_sql", func(args map[string]interface{}) (interface{}, error) {
+		if args["query"] != "SELECT 1 AS one" {
+			return nil, &Error{Status: http.StatusBadRequest, Code: "42601", Message: "syntax error"}
+		}
+		return []map[string]interface{}{{"one": 1}}, nil
+	})
+	client := supabaseorm.New(fake.URL, "test-key")
+
+	var rows []map[string]interface{}
+	if err := client.Table("").Raw("SELECT 1 AS one").Get(&rows); err != nil || len(rows) != 1 || rows[0]["one"] != float64(1) {
+		t.Errorf("Unexpected raw query result %v, %v", rows, err)
+	}
+
+	err := client.Table("").Raw("SELEC").Get(&rows)
+	var apiErr *supabaseorm.PostgrestError
+	if !errors.As(err, &apiErr) || apiErr.Code != "42601" {
+		t.Errorf("Expected the RPC error, got %v", err)
+	}
+
+	var sum float64
+	resp, err := client.RawRequest().SetBody(map[string]int{"a": 2, "b": 3}).SetResult(&sum).Post(fake.URL + "/rest/v1/rpc/add")
+	if err != nil || resp.IsError() || sum != 5 {
+		t.Errorf("Unexpected RPC result %v, %v", sum, err)
+	}
+}
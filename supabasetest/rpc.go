@@ -0,0 +1,75 @@
+package supabasetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RPCFunc implements a database function called through /rest/v1/rpc.
+// args holds the JSON body of POST calls, or the query parameters of GET
+// calls. The result is sent as JSON; returning an *Error sets the status
+// and code of the error response
+type RPCFunc func(args map[string]interface{}) (interface{}, error)
+
+// HandleRPC registers the function called by /rest/v1/rpc/name
+//
+// Raw queries of supabaseorm call the execute_sql function
+func (s *Server) HandleRPC(name string, fn RPCFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rpcs[name] = fn
+}
+
+// serveRPC calls a registered function
+func (s *Server) serveRPC(w http.ResponseWriter, r *http.Request, name string) {
+	s.mu.Lock()
+	fn, ok := s.rpcs[name]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, &Error{
+			Status:  http.StatusNotFound,
+			Code:    "PGRST202",
+			Message: fmt.Sprintf("Could not find the function public.%s in the schema cache", name),
+			Hint:    "Register it with Server.HandleRPC",
+		})
+		return
+	}
+
+	args := make(map[string]interface{})
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		for key, values := range r.URL.Query() {
+			if !reservedParams[key] && len(values) > 0 {
+				args[key] = values[0]
+			}
+		}
+	case http.MethodPost:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &args); err != nil {
+				writeError(w, badRequest("RPC arguments must be a JSON object: %v", err))
+				return
+			}
+		}
+	default:
+		writeError(w, &Error{Status: http.StatusMethodNotAllowed, Code: "PGRST117", Message: "unsupported HTTP method " + r.Method})
+		return
+	}
+
+	result, err := fn(args)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
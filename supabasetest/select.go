@@ -0,0 +1,180 @@
+package supabasetest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// selectItem is a column or embedded table of a select parameter
+type selectItem struct {
+	alias  string
+	column string
+	embed  *embed
+}
+
+// embed is an embedded table, e.g. posts(id,title) or author:users!inner(*)
+type embed struct {
+	table string
+	hint  string
+	inner bool
+	items []selectItem
+}
+
+// parseSelect parses a select parameter such as id,name,posts(*)
+func parseSelect(value string) ([]selectItem, error) {
+	if value == "" {
+		return []selectItem{{column: "*"}}, nil
+	}
+
+	var items []selectItem
+	for _, part := range splitTopLevel(value) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		item := selectItem{}
+		if alias, rest, ok := strings.Cut(part, ":"); ok && !strings.HasPrefix(rest, ":") {
+			item.alias, part = alias, rest
+		}
+
+		if open := strings.Index(part, "("); open >= 0 {
+			if !strings.HasSuffix(part, ")") {
+				return nil, badRequest("invalid embedded resource %q", part)
+			}
+			e := &embed{}
+			name := strings.Split(part[:open], "!")
+			e.table = name[0]
+			for _, modifier := range name[1:] {
+				if modifier == "inner" {
+					e.inner = true
+				} else if modifier != "left" {
+					e.hint = modifier
+				}
+			}
+
+			var err error
+			if e.items, err = parseSelect(part[open+1 : len(part)-1]); err != nil {
+				return nil, err
+			}
+			if len(e.items) == 0 {
+				e.items = []selectItem{{column: "*"}}
+			}
+			item.embed = e
+		} else {
+			// Casts such as amount::text are ignored
+			item.column, _, _ = strings.Cut(part, "::")
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// project returns rows of the named table with the selected columns and
+// embedded tables
+func (s *Server) project(name string, rows []map[string]interface{}, items []selectItem) ([]map[string]interface{}, error) {
+	projected := make([]map[string]interface{}, 0, len(rows))
+
+rows:
+	for _, row := range rows {
+		out := make(map[string]interface{})
+		for _, item := range items {
+			switch {
+			case item.embed != nil:
+				value, err := s.resolveEmbed(name, row, item.embed)
+				if err != nil {
+					return nil, err
+				}
+				if item.embed.inner && isEmpty(value) {
+					continue rows
+				}
+				key := item.alias
+				if key == "" {
+					key = item.embed.table
+				}
+				out[key] = value
+			case item.column == "*":
+				for column, value := range row {
+					out[column] = value
+				}
+			default:
+				key := item.alias
+				if key == "" {
+					key = item.column
+				}
+				out[key] = row[item.column]
+			}
+		}
+		projected = append(projected, out)
+	}
+	return projected, nil
+}
+
+// resolveEmbed returns the rows of e related to row of the named table:
+// an array for one-to-many relationships, an object or nil for many-to-one
+func (s *Server) resolveEmbed(name string, row map[string]interface{}, e *embed) (interface{}, error) {
+	related, ok := s.tables[e.table]
+	if !ok {
+		return nil, s.missingRelationship(name, e.table)
+	}
+
+	for _, fk := range s.foreignKeys {
+		if e.hint != "" && fk.column != e.hint {
+			continue
+		}
+
+		// The embedded table references row: one-to-many
+		if fk.table == e.table && fk.foreignTable == name {
+			var children []map[string]interface{}
+			for _, child := range related.rows {
+				if child[fk.column] != nil && row[fk.foreignColumn] != nil &&
+					compareValues(child[fk.column], row[fk.foreignColumn]) == 0 {
+					children = append(children, child)
+				}
+			}
+			return s.project(e.table, children, e.items)
+		}
+
+		// Row references the embedded table: many-to-one
+		if fk.table == name && fk.foreignTable == e.table {
+			if row[fk.column] == nil {
+				return nil, nil
+			}
+			for _, parent := range related.rows {
+				if parent[fk.foreignColumn] != nil && compareValues(parent[fk.foreignColumn], row[fk.column]) == 0 {
+					projected, err := s.project(e.table, []map[string]interface{}{parent}, e.items)
+					if err != nil || len(projected) == 0 {
+						return nil, err
+					}
+					return projected[0], nil
+				}
+			}
+			return nil, nil
+		}
+	}
+
+	return nil, s.missingRelationship(name, e.table)
+}
+
+func (s *Server) missingRelationship(table, embedded string) *Error {
+	return &Error{
+		Status:  http.StatusBadRequest,
+		Code:    "PGRST200",
+		Message: fmt.Sprintf("Could not find a relationship between '%s' and '%s' in the schema cache", table, embedded),
+		Hint:    "Declare it with Server.ForeignKey",
+	}
+}
+
+// isEmpty reports whether an embedded value has no rows
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case []map[string]interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return v == nil
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Package supabasetest provides an in-memory fake of the Supabase REST
+// (PostgREST) and auth (GoTrue) APIs for tests
+//
+//	fake := supabasetest.New(t)
+//	fake.Seed("users", map[string]interface{}{"id": 1, "name": "Jane"})
+//	client := supabaseorm.New(fake.URL, "test-key")
+//
+// The fake implements a subset of PostgREST: selecting columns and
+// embedded tables, the common filter operators and logic trees, order,
+// limit and offset, exact counts, inserts, upserts, updates and deletes
+// with return=representation, and RPC functions registered with HandleRPC.
+// Row level security and schemas are not modeled
+package supabasetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Server is a fake Supabase API over in-memory tables
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	tables      map[string]*table
+	foreignKeys []foreignKey
+	rpcs        map[string]RPCFunc
+	auth        *gotrue
+}
+
+// NewServer starts a fake Supabase API. The caller must Close it
+func NewServer() *Server {
+	s := &Server{
+		tables: make(map[string]*table),
+		rpcs:   make(map[string]RPCFunc),
+		auth:   newGoTrue(),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// New starts a fake Supabase API that is closed when the test ends
+func New(t testing.TB) *Server {
+	s := NewServer()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Error is an API error. RPC functions return it to choose the status
+// and code of their error response
+type Error struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details,omitempty"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// serveHTTP routes requests to the REST and auth fakes
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/rest/v1/rpc/"):
+		s.serveRPC(w, r, strings.TrimPrefix(r.URL.Path, "/rest/v1/rpc/"))
+	case strings.HasPrefix(r.URL.Path, "/rest/v1/"):
+		s.serveTable(w, r, strings.TrimPrefix(r.URL.Path, "/rest/v1/"))
+	case strings.HasPrefix(r.URL.Path, "/auth/v1/"):
+		s.auth.serveHTTP(w, r, strings.TrimPrefix(r.URL.Path, "/auth/v1/"))
+	default:
+		writeError(w, &Error{Status: http.StatusNotFound, Code: "PGRST000", Message: "not found: " + r.URL.Path})
+	}
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a PostgREST error response
+func writeError(w http.ResponseWriter, err error) {
+	apiErr, ok := err.(*Error)
+	if !ok {
+		apiErr = &Error{Status: http.StatusBadRequest, Code: "P0001", Message: err.Error()}
+	}
+	status := apiErr.Status
+	if status == 0 {
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, apiErr)
+}
+
+// badRequest returns a PostgREST parse error
+func badRequest(format string, args ...interface{}) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: "PGRST100", Message: fmt.Sprintf(format, args...)}
+}
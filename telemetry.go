@@ -0,0 +1,315 @@
+package supabaseorm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer and meter of the client
+const instrumentationName = "github.com/zoc/supabase-orm"
+
+// Attribute keys of the client's spans and metrics
+const (
+	AttrOperation   = attribute.Key("supabase.operation")
+	AttrTable       = attribute.Key("supabase.table")
+	AttrSchema      = attribute.Key("supabase.schema")
+	AttrFilterCount = attribute.Key("supabase.filter_count")
+	AttrRowCount    = attribute.Key("supabase.row_count")
+	AttrErrorCode   = attribute.Key("supabase.error_code")
+	AttrRetries     = attribute.Key("supabase.retries")
+	AttrStatusCode  = attribute.Key("http.response.status_code")
+)
+
+// WithTracerProvider creates a span for every request of the client and
+// propagates the trace context with traceparent headers
+func WithTracerProvider(provider trace.TracerProvider) ClientOption {
+	return func(c *Client) {
+		c.telemetry().tracer = provider.Tracer(instrumentationName)
+	}
+}
+
+// WithMeterProvider records the duration, errors and retries of the
+// client's requests
+func WithMeterProvider(provider metric.MeterProvider) ClientOption {
+	return func(c *Client) {
+		c.telemetry().meter = provider.Meter(instrumentationName)
+	}
+}
+
+// WithPropagator sets the propagator used to inject the trace context into
+// requests, by default the W3C trace context (traceparent)
+func WithPropagator(propagator propagation.TextMapPropagator) ClientOption {
+	return func(c *Client) {
+		c.telemetry().propagator = propagator
+	}
+}
+
+// telemetryConfig holds the instrumentation options of a client
+type telemetryConfig struct {
+	tracer     trace.Tracer
+	meter      metric.Meter
+	propagator propagation.TextMapPropagator
+}
+
+// telemetry returns the client's instrumentation options, creating them on first use
+func (c *Client) telemetry() *telemetryConfig {
+	if c.otel == nil {
+		c.otel = &telemetryConfig{}
+	}
+	return c.otel
+}
+
+// operationInfo describes the query a request belongs to
+type operationInfo struct {
+	operation string
+	table     string
+	schema    string
+	filters   int
+}
+
+type operationKey struct{}
+
+// withOperation returns a context describing the query's operation
+func (q *QueryBuilder) withOperation(ctx context.Context) context.Context {
+	info := operationInfo{
+		operation: q.operation(),
+		table:     q.tableName,
+		schema:    q.schema,
+		filters:   len(q.requestFilters()),
+	}
+	if q.rawQuery != "" {
+		info.table = ""
+	}
+	return context.WithValue(ctx, operationKey{}, info)
+}
+
+// operation names the query's operation
+func (q *QueryBuilder) operation() string {
+	if q.rawQuery != "" || q.rpc != "" {
+		return "rpc"
+	}
+	switch q.method {
+	case http.MethodGet:
+		return "select"
+	case http.MethodHead:
+		return "count"
+	case http.MethodPost:
+		if strings.Contains(q.headers["Prefer"], "resolution=") {
+			return "upsert"
+		}
+		return "insert"
+	case http.MethodPatch:
+		return "update"
+	case http.MethodDelete:
+		return "delete"
+	}
+	return strings.ToLower(q.method)
+}
+
+// requestOperation returns the operation of a request, from its context
+// or, for auth, functions and other APIs, from its path
+func requestOperation(req *http.Request) operationInfo {
+	if info, ok := req.Context().Value(operationKey{}).(operationInfo); ok {
+		return info
+	}
+
+	// e.g. /auth/v1/token -> auth.token, /functions/v1/hello -> functions.hello
+	path := strings.Trim(req.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i+1] == "v1" && i+2 < len(parts) {
+			return operationInfo{operation: parts[i] + "." + parts[i+2]}
+		}
+	}
+	return operationInfo{operation: strings.ToLower(req.Method)}
+}
+
+// telemetryTransport traces and measures requests
+type telemetryTransport struct {
+	next       http.RoundTripper
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	duration metric.Float64Histogram
+	errors   metric.Int64Counter
+	retries  metric.Int64Counter
+}
+
+func newTelemetryTransport(next http.RoundTripper, config *telemetryConfig) *telemetryTransport {
+	t := &telemetryTransport{
+		next:       next,
+		tracer:     config.tracer,
+		propagator: config.propagator,
+	}
+	if t.propagator == nil {
+		t.propagator = propagation.TraceContext{}
+	}
+
+	if config.meter != nil {
+		// Instruments are usable even when creating them fails
+		var err error
+		t.duration, err = config.meter.Float64Histogram("supabase.client.request.duration",
+			metric.WithDescription("Duration of Supabase requests"), metric.WithUnit("s"))
+		otel.Handle(err)
+		t.errors, err = config.meter.Int64Counter("supabase.client.errors",
+			metric.WithDescription("Failed Supabase requests by error code"))
+		otel.Handle(err)
+		t.retries, err = config.meter.Int64Counter("supabase.client.retries",
+			metric.WithDescription("Retries of Supabase requests"))
+		otel.Handle(err)
+	}
+
+	return t
+}
+
+func (t *telemetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	info := requestOperation(req)
+
+	attrs := []attribute.KeyValue{AttrOperation.String(info.operation)}
+	if info.table != "" {
+		attrs = append(attrs, AttrTable.String(info.table))
+	}
+	if info.schema != "" {
+		attrs = append(attrs, AttrSchema.String(info.schema))
+	}
+
+	ctx := req.Context()
+	var span trace.Span
+	if t.tracer != nil {
+		name := "supabase " + info.operation
+		if info.table != "" {
+			name += " " + info.table
+		}
+		ctx, span = t.tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...),
+			trace.WithAttributes(AttrFilterCount.Int(info.filters)))
+		defer span.End()
+	}
+
+	// Count the attempts of the retry transport, if the query does not already
+	attempts, ok := ctx.Value(attemptsKey{}).(*int)
+	if !ok {
+		attempts = new(int)
+		ctx = withAttemptCounter(ctx, attempts)
+	}
+
+	req = req.Clone(ctx)
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.next.RoundTrip(req)
+
+	var resultAttrs []attribute.KeyValue
+	errorCode := ""
+	switch {
+	case err != nil:
+		errorCode = "network"
+	case resp.StatusCode >= 400:
+		errorCode = responseErrorCode(resp)
+	}
+
+	resultAttrs = append(resultAttrs, statusAttr(resp)...)
+	if resp != nil {
+		if rows, ok := contentRangeRows(resp.Header.Get("Content-Range")); ok {
+			resultAttrs = append(resultAttrs, AttrRowCount.Int(rows))
+		}
+	}
+	if errorCode != "" {
+		resultAttrs = append(resultAttrs, AttrErrorCode.String(errorCode))
+	}
+	retries := 0
+	if *attempts > 1 {
+		retries = *attempts - 1
+		resultAttrs = append(resultAttrs, AttrRetries.Int(retries))
+	}
+
+	if span != nil {
+		span.SetAttributes(resultAttrs...)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else if resp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", resp.StatusCode))
+		}
+	}
+
+	if t.duration != nil {
+		t.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...), metric.WithAttributes(statusAttr(resp)...))
+		if errorCode != "" {
+			t.errors.Add(ctx, 1, metric.WithAttributes(attrs...), metric.WithAttributes(AttrErrorCode.String(errorCode)))
+		}
+		if retries > 0 {
+			t.retries.Add(ctx, int64(retries), metric.WithAttributes(attrs...))
+		}
+	}
+
+	return resp, err
+}
+
+// statusAttr returns the status code attribute of resp, if any
+func statusAttr(resp *http.Response) []attribute.KeyValue {
+	if resp == nil {
+		return nil
+	}
+	return []attribute.KeyValue{AttrStatusCode.Int(resp.StatusCode)}
+}
+
+// maxErrorBody caps how much of an error response is read for its code
+const maxErrorBody = 64 << 10
+
+// responseErrorCode returns the PostgREST error code of an error response,
+// or its status code. The body is restored for the caller
+func responseErrorCode(resp *http.Response) string {
+	code := strconv.Itoa(resp.StatusCode)
+	if resp.Body == nil {
+		return code
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), resp.Body), Closer: resp.Body}
+	if err != nil {
+		return code
+	}
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Code != "" {
+		return body.Code
+	}
+	return code
+}
+
+// readCloser combines a reader with the closer of the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// contentRangeRows returns the number of rows in a Content-Range header
+// such as "0-9/100" or "*/0". The count of "*/*" is unknown
+func contentRangeRows(contentRange string) (int, bool) {
+	if contentRange == "" || contentRange == "*/*" {
+		return 0, false
+	}
+	if strings.HasPrefix(contentRange, "*/") {
+		return 0, true
+	}
+	start, end, _ := ParseContentRange(contentRange)
+	return end - start + 1, true
+}
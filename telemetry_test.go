@@ -0,0 +1,157 @@
+package supabaseorm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracing(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code":"23503","message":"foreign key violation"}`))
+			return
+		}
+		w.Header().Set("Content-Range", "0-1/*")
+		w.Write([]byte(`[{"id":1},{"id":2}]`))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := New(server.URL, "test-api-key", WithTracerProvider(provider), WithSchema("api"))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	var rows []map[string]interface{}
+	if err := client.Table("users").WithContext(ctx).Where("active", "eq", true).Get(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	parent.End()
+
+	client.Table("users").Where("id", "eq", 1).Delete()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+
+	get := spans[0]
+	if get.Name() != "supabase select users" {
+		t.Errorf("Unexpected span name %q", get.Name())
+	}
+	if get.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("Expected the span to be a child of the caller's span")
+	}
+	if spanAttr(get, AttrSchema).AsString() != "api" || spanAttr(get, AttrFilterCount).AsInt64() != 1 ||
+		spanAttr(get, AttrRowCount).AsInt64() != 2 || spanAttr(get, AttrStatusCode).AsInt64() != 200 {
+		t.Errorf("Unexpected attributes %v", get.Attributes())
+	}
+
+	del := spans[2]
+	if spanAttr(del, AttrErrorCode).AsString() != "23503" || del.Status().Code.String() != "Error" {
+		t.Errorf("Expected the PostgREST error code on the span, got %v %v", del.Attributes(), del.Status())
+	}
+
+	if traceparent == "" {
+		t.Error("Expected a traceparent header")
+	}
+}
+
+func TestTracingSentFiltersAndUnknownCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "*/*")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := New(server.URL, "test-api-key", WithTracerProvider(provider), WithSoftDelete("posts", "deleted_at")).
+		WithScope("tenant", tenantScope(7))
+
+	var rows []map[string]interface{}
+	if err := client.Table("posts").Where("id", "eq", 1).Get(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	span := recorder.Ended()[0]
+	if count := spanAttr(span, AttrFilterCount).AsInt64(); count != 3 {
+		t.Errorf("Expected the soft delete and scope filters to be counted, got %d", count)
+	}
+	if value := spanAttr(span, AttrRowCount); value.Type() != attribute.INVALID {
+		t.Errorf("Expected no row count for an unknown count, got %v", value.Emit())
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	client := New(server.URL, "test-api-key",
+		WithMeterProvider(provider),
+		WithRetry(RetryPolicy{Backoff: ConstantBackoff(0)}))
+
+	var row map[string]interface{}
+	if err := client.Table("users").Get(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Auth().GetUser(context.Background(), "token"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]bool{}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			found[m.Name] = true
+			switch m.Name {
+			case "supabase.client.request.duration":
+				points := m.Data.(metricdata.Histogram[float64]).DataPoints
+				if len(points) != 2 {
+					t.Errorf("Expected durations for the select and auth.user operations, got %d", len(points))
+				}
+			case "supabase.client.retries":
+				points := m.Data.(metricdata.Sum[int64]).DataPoints
+				if len(points) != 1 || points[0].Value != 1 {
+					t.Errorf("Expected 1 retry, got %v", points)
+				}
+			}
+		}
+	}
+	if !found["supabase.client.request.duration"] || !found["supabase.client.retries"] {
+		t.Errorf("Expected duration and retry metrics, got %v", found)
+	}
+}
@@ -2,7 +2,6 @@ package supabaseorm
 
 import (
 	"fmt"
-	"net/http"
 )
 
 // Transaction represents a database transaction
@@ -19,11 +18,7 @@ func (c *Client) Begin() *Transaction {
 
 // Table returns a new query builder for the specified table within the transaction
 func (t *Transaction) Table(tableName string) *QueryBuilder {
-	builder := &QueryBuilder{
-		client:    t.client,
-		tableName: tableName,
-		method:    http.MethodGet,
-	}
+	builder := t.client.Table(tableName)
 
 	// Add transaction headers
 	builder.Header("Prefer", "tx=commit")
@@ -72,6 +72,72 @@ func BuildFilterCondition(column, operator string, value interface{}) string {
 	}
 }
 
+// normalizeOperator maps SQL-style operators to PostgREST operators
+func normalizeOperator(operator string) string {
+	switch operator {
+	case "=":
+		return "eq"
+	case "!=", "<>":
+		return "neq"
+	case ">":
+		return "gt"
+	case ">=":
+		return "gte"
+	case "<":
+		return "lt"
+	case "<=":
+		return "lte"
+	default:
+		return operator
+	}
+}
+
+// formatParamValue formats a filter value for a query parameter
+// Values inside logical trees (inTree) are quoted when they contain
+// characters reserved by the PostgREST grammar
+func formatParamValue(operator string, value interface{}, inTree bool) string {
+	if value == nil {
+		return "null"
+	}
+
+	v := reflect.ValueOf(value)
+	if operator == "in" && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) {
+		var items []string
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, quoteTreeValue(fmt.Sprintf("%v", v.Index(i).Interface()), true))
+		}
+		return fmt.Sprintf("(%s)", strings.Join(items, ","))
+	}
+
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		return FormatFilterValue(value)
+	}
+
+	return quoteTreeValue(fmt.Sprintf("%v", value), inTree)
+}
+
+// quoteTreeValue double-quotes s if quote is set and s contains reserved characters
+func quoteTreeValue(s string, quote bool) string {
+	if !quote || !strings.ContainsAny(s, ",.:()\" \\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// rpcArgValue formats a function argument for a query parameter, with
+// slices as PostgreSQL array literals
+func rpcArgValue(value interface{}) string {
+	v := reflect.ValueOf(value)
+	if value != nil && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = quoteTreeValue(fmt.Sprintf("%v", v.Index(i).Interface()), true)
+		}
+		return "{" + strings.Join(items, ",") + "}"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
 // ParseContentRange parses a Content-Range header
 func ParseContentRange(contentRange string) (start, end, total int) {
 	// Format: "items start-end/total"